@@ -0,0 +1,210 @@
+// Package analysis measures peak and RMS levels, DC offset, clipping and
+// silence of WAVE files in a single pass.
+package analysis
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bake/wave"
+)
+
+// Options configures an Analyzer. Zero values are replaced by defaults.
+type Options struct {
+	ClipLevel        float64       // Absolute level at which samples are clipped. Defaults to the largest sample.
+	MinClipRun       int           // Minimum number of consecutive clipped samples to report. Defaults to 1.
+	SilenceThreshold float64       // Level in dBFS below which frames are silent. Defaults to -60.
+	MinSilence       time.Duration // Minimum duration of reported silence. Defaults to 500ms.
+	Oversampling     int           // Oversampling factor of the true peak meter. Defaults to 4.
+}
+
+// Range is a range of frames [Start, End).
+type Range struct {
+	Start, End int64
+}
+
+// Channel contains the levels of a single channel. All levels are linear and
+// relative to full scale.
+type Channel struct {
+	Peak     float64 // Maximum absolute sample value.
+	TruePeak float64 // Maximum absolute value of the oversampled signal.
+	RMS      float64 // Root mean square.
+	DCOffset float64 // Mean of all samples.
+	Clipped  []Range // Runs of clipped samples.
+}
+
+// Report is the result of an analysis.
+type Report struct {
+	Frames   int64
+	Channels []Channel
+	Silence  []Range // Ranges in which all channels are silent.
+}
+
+// Analyzer collects levels of interleaved samples.
+type Analyzer struct {
+	opts      Options
+	chans     int
+	frames    int64
+	minClip   int64
+	minSilent int64
+	silence   float64
+	truePeak  *TruePeak
+	peak      []float64
+	sum       []float64
+	sumSq     []float64
+	clipped   [][]Range
+	clipStart []int64 // Start of the current run of clipped samples or -1.
+	silent    int64   // Start of the current silence or -1.
+	silences  []Range
+}
+
+// New creates an Analyzer for samples of the given format.
+func New(format wave.Format, opts Options) (*Analyzer, error) {
+	if err := format.CheckFrames(); err != nil {
+		return nil, err
+	}
+	if opts.ClipLevel == 0 {
+		fs := float64(uint64(1) << (format.BitsPerSample - 1))
+		opts.ClipLevel = (fs - 1) / fs
+	}
+	if opts.MinClipRun == 0 {
+		opts.MinClipRun = 1
+	}
+	if opts.SilenceThreshold == 0 {
+		opts.SilenceThreshold = -60
+	}
+	if opts.MinSilence == 0 {
+		opts.MinSilence = 500 * time.Millisecond
+	}
+	if opts.Oversampling == 0 {
+		opts.Oversampling = 4
+	}
+	chans := int(format.NumChans)
+	truePeak, err := NewTruePeak(chans, opts.Oversampling)
+	if err != nil {
+		return nil, err
+	}
+	a := &Analyzer{
+		opts:      opts,
+		chans:     chans,
+		minClip:   int64(opts.MinClipRun),
+		minSilent: int64(opts.MinSilence.Seconds() * float64(format.SampleRate)),
+		silence:   FromDB(opts.SilenceThreshold),
+		truePeak:  truePeak,
+		peak:      make([]float64, chans),
+		sum:       make([]float64, chans),
+		sumSq:     make([]float64, chans),
+		clipped:   make([][]Range, chans),
+		clipStart: make([]int64, chans),
+		silent:    -1,
+	}
+	for i := range a.clipStart {
+		a.clipStart[i] = -1
+	}
+	return a, nil
+}
+
+// Process analyzes interleaved samples. Incomplete frames are ignored.
+func (a *Analyzer) Process(samples []float64) {
+	a.truePeak.Process(samples)
+	for i := 0; i+a.chans <= len(samples); i += a.chans {
+		silent := true
+		for c, v := range samples[i : i+a.chans] {
+			abs := math.Abs(v)
+			if abs > a.peak[c] {
+				a.peak[c] = abs
+			}
+			a.sum[c] += v
+			a.sumSq[c] += v * v
+			if abs >= a.opts.ClipLevel {
+				if a.clipStart[c] < 0 {
+					a.clipStart[c] = a.frames
+				}
+			} else {
+				a.endClip(c)
+			}
+			if abs >= a.silence {
+				silent = false
+			}
+		}
+		if silent && a.silent < 0 {
+			a.silent = a.frames
+		} else if !silent {
+			a.endSilence()
+		}
+		a.frames++
+	}
+}
+
+// endClip finishes the current run of clipped samples of a channel.
+func (a *Analyzer) endClip(c int) {
+	if a.clipStart[c] < 0 {
+		return
+	}
+	if a.frames-a.clipStart[c] >= a.minClip {
+		a.clipped[c] = append(a.clipped[c], Range{a.clipStart[c], a.frames})
+	}
+	a.clipStart[c] = -1
+}
+
+// endSilence finishes the current silence.
+func (a *Analyzer) endSilence() {
+	if a.silent < 0 {
+		return
+	}
+	if a.frames-a.silent >= a.minSilent {
+		a.silences = append(a.silences, Range{a.silent, a.frames})
+	}
+	a.silent = -1
+}
+
+// Report finishes the analysis and returns its result. No more samples may be
+// processed afterwards.
+func (a *Analyzer) Report() *Report {
+	for c := range a.clipStart {
+		a.endClip(c)
+	}
+	a.endSilence()
+	r := &Report{Frames: a.frames, Channels: make([]Channel, a.chans), Silence: a.silences}
+	for c := range r.Channels {
+		ch := Channel{
+			Peak:     a.peak[c],
+			TruePeak: math.Max(a.truePeak.Peak(c), a.peak[c]),
+			Clipped:  a.clipped[c],
+		}
+		if a.frames > 0 {
+			ch.RMS = math.Sqrt(a.sumSq[c] / float64(a.frames))
+			ch.DCOffset = a.sum[c] / float64(a.frames)
+		}
+		r.Channels[c] = ch
+	}
+	return r
+}
+
+// Analyze reads all samples from r until EOF or until ctx is done and returns
+// their levels.
+func Analyze(ctx context.Context, r *wave.Reader, opts Options) (*Report, error) {
+	a, err := New(r.Format, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = wave.ReadBlocks(ctx, r, func(block []float64) error {
+		a.Process(block)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.Report(), nil
+}
+
+// DB converts a linear level to decibels.
+func DB(v float64) float64 {
+	return 20 * math.Log10(v)
+}
+
+// FromDB converts decibels to a linear level.
+func FromDB(db float64) float64 {
+	return math.Pow(10, db/20)
+}
@@ -0,0 +1,126 @@
+package analysis_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/analysis"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/pkg/errors"
+)
+
+// newReader writes interleaved samples into a 16 bit wave file and returns a
+// reader of it.
+func newReader(t *testing.T, chans, rate int, samples []float64) *wave.Reader {
+	return wavetest.Floats(t, wavetest.Format(chans, rate, 16), samples)
+}
+
+func round(v float64) string { return fmt.Sprintf("%.3f", v) }
+
+func TestAnalyze(t *testing.T) {
+	// Left: a constant of 0.25 with a clipped run of three samples at 10.
+	// Right: silence between 20 and 60, a single clipped sample at 70.
+	var samples []float64
+	for i := 0; i < 100; i++ {
+		l, r := 0.25, 0.5
+		if i >= 10 && i < 13 {
+			l = 1
+		}
+		if i >= 20 && i < 60 {
+			l, r = 0, 0
+		}
+		if i == 70 {
+			r = -1
+		}
+		samples = append(samples, l, r)
+	}
+	wavr := newReader(t, 2, 100, samples)
+	report, err := analysis.Analyze(context.Background(), wavr, analysis.Options{
+		MinClipRun: 2,
+		MinSilence: 300 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("could not analyze samples: %v", err)
+	}
+	if report.Frames != 100 {
+		t.Fatalf("expected 100 frames, got %d", report.Frames)
+	}
+	if got := fmt.Sprint(report.Silence); got != "[{20 60}]" {
+		t.Fatalf("expected silence to be [{20 60}], got %s", got)
+	}
+	tt := []struct {
+		peak, rms, dc string
+		clipped       string
+	}{
+		{"1.000", "0.256", "0.172", "[{10 13}]"},
+		{"1.000", "0.397", "0.285", "[]"},
+	}
+	for i, tc := range tt {
+		ch := report.Channels[i]
+		if round(ch.Peak) != tc.peak {
+			t.Fatalf("expected peak of channel %d to be %s, got %s", i, tc.peak, round(ch.Peak))
+		}
+		if round(ch.RMS) != tc.rms {
+			t.Fatalf("expected rms of channel %d to be %s, got %s", i, tc.rms, round(ch.RMS))
+		}
+		if round(ch.DCOffset) != tc.dc {
+			t.Fatalf("expected dc offset of channel %d to be %s, got %s", i, tc.dc, round(ch.DCOffset))
+		}
+		if fmt.Sprint(ch.Clipped) != tc.clipped {
+			t.Fatalf("expected clipped samples of channel %d to be %s, got %v", i, tc.clipped, ch.Clipped)
+		}
+	}
+}
+
+func TestTruePeak(t *testing.T) {
+	// A sine at a quarter of the sample rate shifted by 45 degrees never has a
+	// sample at its peak.
+	var samples []float64
+	for i := 0; i < 1000; i++ {
+		samples = append(samples, 0.5*math.Sin(math.Pi/2*float64(i)+math.Pi/4))
+	}
+	wavr := newReader(t, 1, 48000, samples)
+	report, err := analysis.Analyze(context.Background(), wavr, analysis.Options{})
+	if err != nil {
+		t.Fatalf("could not analyze samples: %v", err)
+	}
+	ch := report.Channels[0]
+	if round(ch.Peak) != "0.354" {
+		t.Fatalf("expected peak to be 0.354, got %s", round(ch.Peak))
+	}
+	if math.Abs(ch.TruePeak-0.5) > 0.01 {
+		t.Fatalf("expected true peak to be about 0.5, got %f", ch.TruePeak)
+	}
+}
+
+func TestAnalyzeCanceled(t *testing.T) {
+	wavr := newReader(t, 1, 8000, make([]float64, 10))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := analysis.Analyze(ctx, wavr, analysis.Options{}); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestAnalyzeNoChannels(t *testing.T) {
+	_, err := analysis.Analyze(context.Background(), wavetest.NoChannels(t), analysis.Options{})
+	if !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := analysis.New(wave.Format{}, analysis.Options{}); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+	if _, err := analysis.NewTruePeak(0, 4); err == nil {
+		t.Fatalf("expected a true peak meter without channels to be rejected")
+	}
+	if _, err := analysis.NewTruePeak(2, 0); err == nil {
+		t.Fatalf("expected a true peak meter without oversampling to be rejected")
+	}
+}
@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// tapsPerPhase is the length of the interpolation filter per phase.
+const tapsPerPhase = 12
+
+// TruePeak measures the peak level between samples by oversampling the signal
+// as recommended by ITU-R BS.1770.
+type TruePeak struct {
+	factor  int
+	chans   int
+	coeffs  [][]float64 // Filter coefficients per phase.
+	history [][]float64 // Last samples per channel, newest first.
+	peak    []float64
+}
+
+// NewTruePeak creates a true peak meter for chans interleaved channels that
+// oversamples by factor.
+func NewTruePeak(chans, factor int) (*TruePeak, error) {
+	if chans < 1 {
+		return nil, errors.Errorf("expected at least one channel, got %d", chans)
+	}
+	if factor < 1 {
+		return nil, errors.Errorf("expected an oversampling factor of at least 1, got %d", factor)
+	}
+	tp := &TruePeak{
+		factor:  factor,
+		chans:   chans,
+		coeffs:  make([][]float64, factor),
+		history: make([][]float64, chans),
+		peak:    make([]float64, chans),
+	}
+	// A windowed sinc interpolation filter, split into its phases.
+	n := tapsPerPhase * factor
+	center := float64(n-1) / 2
+	for p := range tp.coeffs {
+		tp.coeffs[p] = make([]float64, tapsPerPhase)
+		for k := range tp.coeffs[p] {
+			x := (float64(k*factor+p) - center) / float64(factor)
+			window := 0.5 - 0.5*math.Cos(2*math.Pi*float64(k*factor+p+1)/float64(n+1))
+			tp.coeffs[p][k] = sinc(x) * window
+		}
+	}
+	for c := range tp.history {
+		tp.history[c] = make([]float64, tapsPerPhase)
+	}
+	return tp, nil
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// Process measures interleaved samples.
+func (tp *TruePeak) Process(samples []float64) {
+	for i := 0; i+tp.chans <= len(samples); i += tp.chans {
+		for c, v := range samples[i : i+tp.chans] {
+			h := tp.history[c]
+			copy(h[1:], h)
+			h[0] = v
+			for _, coeffs := range tp.coeffs {
+				var y float64
+				for k, coeff := range coeffs {
+					y += h[k] * coeff
+				}
+				if y = math.Abs(y); y > tp.peak[c] {
+					tp.peak[c] = y
+				}
+			}
+		}
+	}
+}
+
+// Peak returns the true peak of a channel.
+func (tp *TruePeak) Peak(c int) float64 {
+	return tp.peak[c]
+}
@@ -0,0 +1,185 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// LoudnessUnset marks loudness fields of a Bext that have not been measured.
+const LoudnessUnset = 0x7fff
+
+// Bext holds the broadcast audio extension chunk as specified in EBU Tech 3285.
+// Loudness values are stored in hundredths of LUFS, LU and dBTP.
+type Bext struct {
+	Description          string // At most 256 characters.
+	Originator           string // At most 32 characters.
+	OriginatorReference  string // At most 32 characters.
+	OriginationDate      string // yyyy-mm-dd
+	OriginationTime      string // hh-mm-ss
+	TimeReference        uint64 // First sample since midnight.
+	Version              uint16
+	UMID                 [64]byte
+	LoudnessValue        int16 // Integrated loudness.
+	LoudnessRange        int16
+	MaxTruePeakLevel     int16
+	MaxMomentaryLoudness int16
+	MaxShortTermLoudness int16
+	CodingHistory        string
+}
+
+// bextFields is the binary layout of the fixed size part of a bext chunk.
+type bextFields struct {
+	Description          [256]byte
+	Originator           [32]byte
+	OriginatorReference  [32]byte
+	OriginationDate      [10]byte
+	OriginationTime      [8]byte
+	TimeReference        uint64
+	Version              uint16
+	UMID                 [64]byte
+	LoudnessValue        int16
+	LoudnessRange        int16
+	MaxTruePeakLevel     int16
+	MaxMomentaryLoudness int16
+	MaxShortTermLoudness int16
+	Reserved             [180]byte
+}
+
+// decodeBext decodes a bext chunk.
+func decodeBext(r io.Reader) (*Bext, error) {
+	var f bextFields
+	if err := binary.Read(r, binary.LittleEndian, &f); err != nil {
+		return nil, err
+	}
+	history, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Bext{
+		Description:          cString(f.Description[:]),
+		Originator:           cString(f.Originator[:]),
+		OriginatorReference:  cString(f.OriginatorReference[:]),
+		OriginationDate:      cString(f.OriginationDate[:]),
+		OriginationTime:      cString(f.OriginationTime[:]),
+		TimeReference:        f.TimeReference,
+		Version:              f.Version,
+		UMID:                 f.UMID,
+		LoudnessValue:        f.LoudnessValue,
+		LoudnessRange:        f.LoudnessRange,
+		MaxTruePeakLevel:     f.MaxTruePeakLevel,
+		MaxMomentaryLoudness: f.MaxMomentaryLoudness,
+		MaxShortTermLoudness: f.MaxShortTermLoudness,
+		CodingHistory:        cString(history),
+	}, nil
+}
+
+// encodeFields encodes the fixed size part of a bext chunk. Strings that are
+// too long are truncated.
+func (b *Bext) encodeFields(w io.Writer) error {
+	f := bextFields{
+		TimeReference:        b.TimeReference,
+		Version:              b.Version,
+		UMID:                 b.UMID,
+		LoudnessValue:        b.LoudnessValue,
+		LoudnessRange:        b.LoudnessRange,
+		MaxTruePeakLevel:     b.MaxTruePeakLevel,
+		MaxMomentaryLoudness: b.MaxMomentaryLoudness,
+		MaxShortTermLoudness: b.MaxShortTermLoudness,
+	}
+	copy(f.Description[:], b.Description)
+	copy(f.Originator[:], b.Originator)
+	copy(f.OriginatorReference[:], b.OriginatorReference)
+	copy(f.OriginationDate[:], b.OriginationDate)
+	copy(f.OriginationTime[:], b.OriginationTime)
+	return binary.Write(w, binary.LittleEndian, f)
+}
+
+// encode a bext chunk into an io.Writer.
+func (b *Bext) encode(w io.Writer) error {
+	if err := b.encodeFields(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, b.CodingHistory)
+	return err
+}
+
+// cString returns the bytes up to the first null byte as a string.
+func cString(p []byte) string {
+	if i := bytes.IndexByte(p, 0); i >= 0 {
+		p = p[:i]
+	}
+	return string(p)
+}
+
+// Bext returns the broadcast audio extension chunk. Chunks preceding the first
+// data chunk are read if necessary. It returns nil if there is no bext chunk
+// before the samples that have been read so far.
+func (wavr *Reader) Bext() (*Bext, error) {
+	if wavr.bext != nil || wavr.segment.Index >= 0 || wavr.payload() != nil {
+		return wavr.bext, nil
+	}
+	if err := wavr.seekData(); err != nil {
+		return nil, err
+	}
+	return wavr.bext, nil
+}
+
+// readBext decodes the current chunk as bext chunk.
+func (wavr *Reader) readBext() error {
+	data, err := wavr.buffer(wavr.chunks())
+	if err != nil {
+		return err
+	}
+	b, err := decodeBext(data)
+	if err != nil {
+		return errors.Wrap(err, "could not decode bext chunk")
+	}
+	wavr.bext = b
+	return nil
+}
+
+// BextChunk writes a broadcast audio extension chunk in front of the samples.
+// The fixed size fields are written again when the writer is closed, so b can
+// be updated with values that are only known after writing all samples, like
+// its loudness. Its coding history must not change.
+func BextChunk(b *Bext) WriterOption {
+	return func(wavw *Writer) { wavw.bext = b }
+}
+
+// writeBext writes the bext chunk and remembers its position.
+func (wavw *Writer) writeBext() error {
+	var err error
+	if wavw.bextPos, err = wavw.rw.Seek(0, io.SeekCurrent); err != nil {
+		return errors.Wrap(err, "could not get current position")
+	}
+	cw, err := wavw.rw.Chunk("bext")
+	if err != nil {
+		return errors.Wrap(err, "could not create bext chunk")
+	}
+	if err := wavw.bext.encode(cw); err != nil {
+		return errors.Wrap(err, "could not encode bext chunk")
+	}
+	return errors.Wrap(cw.Close(), "could not close bext chunk")
+}
+
+// rewriteBext overwrites the fixed size fields of the bext chunk.
+func (wavw *Writer) rewriteBext() error {
+	end, err := wavw.ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "could not get current position")
+	}
+	if _, err := wavw.ws.Seek(wavw.bextPos+8, io.SeekStart); err != nil {
+		return errors.Wrap(err, "could not seek to bext chunk")
+	}
+	if err := wavw.bext.encodeFields(wavw.ws); err != nil {
+		return errors.Wrap(err, "could not encode bext chunk")
+	}
+	if _, err := wavw.ws.Seek(end, io.SeekStart); err != nil {
+		return errors.Wrap(err, "could not seek to end of file")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package wave_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+)
+
+func TestBext(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      1,
+		SampleRate:    48000,
+		ByteRate:      96000,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+	}
+	bext := &wave.Bext{
+		Description:     "Scene 1, take 3",
+		Originator:      "wave",
+		OriginationDate: "2019-04-01",
+		OriginationTime: "12-30-00",
+		TimeReference:   48000 * 3600,
+		Version:         2,
+		CodingHistory:   "A=PCM,F=48000,W=16,M=mono\r\n",
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format, wave.BextChunk(bext))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	samples := []int{1, -1, 2, -2, 3}
+	if err := wavw.Samples(samples); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	// Values that are known after writing all samples.
+	bext.LoudnessValue = -2300
+	bext.MaxTruePeakLevel = -100
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+
+	wavr, err := wave.NewReader(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	got, err := wavr.Bext()
+	if err != nil {
+		t.Fatalf("could not read bext chunk: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(bext) {
+		t.Fatalf("expected bext chunk to be\n%v, got\n%v", bext, got)
+	}
+	out, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if fmt.Sprint(out) != fmt.Sprint(samples) {
+		t.Fatalf("expected samples to be %v, got %v", samples, out)
+	}
+}
+
+func TestBextMissing(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	bext, err := wavr.Bext()
+	if err != nil {
+		t.Fatalf("could not read bext chunk: %v", err)
+	}
+	if bext != nil {
+		t.Fatalf("expected no bext chunk, got %v", bext)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if len(samples) != 22 {
+		t.Fatalf("expected 22 samples, got %d", len(samples))
+	}
+}
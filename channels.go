@@ -0,0 +1,64 @@
+package wave
+
+// Speaker positions of a channel mask. Channels are stored in the order of
+// their speakers in this list.
+const (
+	SpeakerFrontLeft uint32 = 1 << iota
+	SpeakerFrontRight
+	SpeakerFrontCenter
+	SpeakerLowFrequency
+	SpeakerBackLeft
+	SpeakerBackRight
+	SpeakerFrontLeftOfCenter
+	SpeakerFrontRightOfCenter
+	SpeakerBackCenter
+	SpeakerSideLeft
+	SpeakerSideRight
+	SpeakerTopCenter
+	SpeakerTopFrontLeft
+	SpeakerTopFrontCenter
+	SpeakerTopFrontRight
+	SpeakerTopBackLeft
+	SpeakerTopBackCenter
+	SpeakerTopBackRight
+)
+
+// DefaultChannelMask returns the usual speaker positions of files with chans
+// channels and no channel mask, or 0 if there is none.
+func DefaultChannelMask(chans int) uint32 {
+	switch chans {
+	case 1:
+		return SpeakerFrontCenter
+	case 2:
+		return SpeakerFrontLeft | SpeakerFrontRight
+	case 3:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerFrontCenter
+	case 4:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerBackLeft | SpeakerBackRight
+	case 5:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerFrontCenter | SpeakerBackLeft | SpeakerBackRight
+	case 6:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerFrontCenter | SpeakerLowFrequency |
+			SpeakerBackLeft | SpeakerBackRight
+	case 8:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerFrontCenter | SpeakerLowFrequency |
+			SpeakerBackLeft | SpeakerBackRight | SpeakerSideLeft | SpeakerSideRight
+	}
+	return 0
+}
+
+// Speakers returns the speaker position of each channel. The default channel
+// mask is used if the format has none. Channels without a position are 0.
+func (f Format) Speakers() []uint32 {
+	mask := f.ChannelMask
+	if mask == 0 {
+		mask = DefaultChannelMask(int(f.NumChans))
+	}
+	speakers := make([]uint32, f.NumChans)
+	for i := range speakers {
+		speaker := mask & -mask // Lowest bit.
+		speakers[i] = speaker
+		mask &^= speaker
+	}
+	return speakers
+}
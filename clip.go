@@ -0,0 +1,84 @@
+package wave
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// ErrClipped is returned by Writer.Sample for samples that are out of range
+// if the writer has been created with Clip(ClipError).
+var ErrClipped = errors.New("sample out of range")
+
+// ClipPolicy decides what a Writer does with integer samples that don't fit
+// into its bit depth.
+type ClipPolicy int
+
+// Clip policies of a Writer.
+const (
+	ClipWrap  ClipPolicy = iota // Keep the lower bits, so samples wrap around.
+	ClipClamp                   // Replace samples by the smallest or largest value.
+	ClipError                   // Return ErrClipped and don't write the sample.
+)
+
+// Clip sets what the writer does with integer samples that are out of range.
+// The default is ClipWrap. Samples written by Floats are always clamped.
+func Clip(policy ClipPolicy) WriterOption {
+	return func(wavw *Writer) { wavw.clip = policy }
+}
+
+// Clipped returns the number of samples that have been out of range so far,
+// including samples written by Floats.
+func (wavw *Writer) Clipped() int64 {
+	return wavw.clipped
+}
+
+// WriteSigned makes the writer expect 8 bit samples as signed values between
+// -128 and 127, like samples of all other bit depths, instead of the unsigned
+// values stored in the file.
+func WriteSigned() WriterOption {
+	return func(wavw *Writer) { wavw.signed = true }
+}
+
+// offset returns the value that is added to samples to convert them from the
+// convention of the writer to the one of the file.
+func (wavw *Writer) offset() int {
+	if wavw.signed && wavw.fmt.BitsPerSample == 8 {
+		return 128
+	}
+	return 0
+}
+
+// limit applies the clip policy to a sample in the convention of the writer.
+func (wavw *Writer) limit(s int) (int, error) {
+	bits := wavw.fmt.BitsPerSample
+	if bits == 0 || bits > 32 {
+		return s, nil
+	}
+	max := 1<<uint(bits-1) - 1
+	min := -max - 1
+	if bits == 8 && !wavw.signed {
+		min, max = 0, 255
+	}
+	if s >= min && s <= max {
+		return s, nil
+	}
+	wavw.clipped++
+	switch wavw.clip {
+	case ClipClamp:
+		if s < min {
+			return min, nil
+		}
+		return max, nil
+	case ClipError:
+		return s, errors.Wrapf(ErrClipped, "%d not in [%d, %d]", s, min, max)
+	}
+	return s, nil
+}
+
+// clips reports if a value in the range [-1, 1) is clipped by Format.int.
+func (f Format) clips(v float64) bool {
+	fs := f.fullScale()
+	v = math.Round(v * fs)
+	return v > fs-1 || v < -fs
+}
@@ -0,0 +1,158 @@
+package wave_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+func TestWriterClip(t *testing.T) {
+	tt := []struct {
+		name    string
+		opts    []wave.WriterOption
+		bps     uint16
+		in      []int
+		out     string
+		clipped int64
+	}{
+		{"wrap", nil, 16, []int{1, 40000, -40000, 32767}, "[1 -25536 25536 32767]", 2},
+		{"clamp", []wave.WriterOption{wave.Clip(wave.ClipClamp)}, 16, []int{1, 40000, -40000, 32767}, "[1 32767 -32768 32767]", 2},
+		{"error", []wave.WriterOption{wave.Clip(wave.ClipError)}, 16, []int{1, 40000, -40000, 32767}, "[1 32767]", 2},
+		{"unsigned", []wave.WriterOption{wave.Clip(wave.ClipClamp)}, 8, []int{-1, 0, 255, 256}, "[0 0 255 255]", 2},
+		{"signed", []wave.WriterOption{wave.Clip(wave.ClipClamp), wave.WriteSigned()}, 8, []int{-129, -128, 0, 127, 128}, "[0 0 128 255 255]", 2},
+		{"24 bit", []wave.WriterOption{wave.Clip(wave.ClipClamp)}, 24, []int{1 << 23, -1<<23 - 1}, "[8388607 -8388608]", 2},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			format := wave.Format{
+				AudioFormat:   1,
+				NumChans:      1,
+				SampleRate:    8000,
+				ByteRate:      8000 * uint32(tc.bps) / 8,
+				BlockAlign:    tc.bps / 8,
+				BitsPerSample: tc.bps,
+			}
+			ws := &writerseeker.WriterSeeker{}
+			wavw, err := wave.NewWriter(ws, format, tc.opts...)
+			if err != nil {
+				t.Fatalf("could not create wave writer: %v", err)
+			}
+			for _, s := range tc.in {
+				if err := wavw.Sample(s); err != nil && !errors.Is(err, wave.ErrClipped) {
+					t.Fatalf("could not write sample %d: %v", s, err)
+				}
+			}
+			if err := wavw.Close(); err != nil {
+				t.Fatalf("could not close wave writer: %v", err)
+			}
+			if wavw.Clipped() != tc.clipped {
+				t.Fatalf("expected %d samples to be clipped, got %d", tc.clipped, wavw.Clipped())
+			}
+			wavr, err := wave.NewReader(ws.Reader())
+			if err != nil {
+				t.Fatalf("could not create wave reader: %v", err)
+			}
+			samples, err := wavr.Samples()
+			if err != nil {
+				t.Fatalf("could not read samples: %v", err)
+			}
+			if fmt.Sprint(samples) != tc.out {
+				t.Fatalf("expected samples to be %s, got %v", tc.out, samples)
+			}
+		})
+	}
+}
+
+func TestWriterClipFloats(t *testing.T) {
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, wave.Format{
+		AudioFormat:   1,
+		NumChans:      1,
+		SampleRate:    8000,
+		ByteRate:      16000,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+	})
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Floats([]float64{0, 1, -1, 1.5, -1.5}); err != nil {
+		t.Fatalf("could not write floats: %v", err)
+	}
+	if wavw.Clipped() != 3 {
+		t.Fatalf("expected 3 samples to be clipped, got %d", wavw.Clipped())
+	}
+}
+
+func TestSigned(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      1,
+		SampleRate:    8000,
+		ByteRate:      8000,
+		BlockAlign:    1,
+		BitsPerSample: 8,
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format, wave.WriteSigned())
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples([]int{-128, -1, 0, 1, 127}); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	for _, tc := range []struct {
+		opts []wave.ReaderOption
+		exp  string
+	}{
+		{nil, "[0 127 128 129 255]"},
+		{[]wave.ReaderOption{wave.ReadSigned()}, "[-128 -1 0 1 127]"},
+	} {
+		wavr, err := wave.NewReader(ws.Reader(), tc.opts...)
+		if err != nil {
+			t.Fatalf("could not create wave reader: %v", err)
+		}
+		samples, err := wavr.Samples()
+		if err != nil {
+			t.Fatalf("could not read samples: %v", err)
+		}
+		if fmt.Sprint(samples) != tc.exp {
+			t.Fatalf("expected samples to be %s, got %v", tc.exp, samples)
+		}
+	}
+
+	// Copying between conventions keeps the samples.
+	wavr, err := wave.NewReader(ws.Reader(), wave.ReadSigned())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	out := &writerseeker.WriterSeeker{}
+	wavw, err = wave.NewWriter(out, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if _, err := wave.Copy(context.Background(), wavw, wavr, nil); err != nil {
+		t.Fatalf("could not copy samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	wavr, err = wave.NewReader(out.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if exp := "[0 127 128 129 255]"; fmt.Sprint(samples) != exp {
+		t.Fatalf("expected samples to be %s, got %v", exp, samples)
+	}
+}
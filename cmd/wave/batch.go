@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/normalize"
+	"github.com/pkg/errors"
+)
+
+// batchInfo describes the format of a file.
+type batchInfo struct {
+	Channels   int     `json:"channels"`
+	SampleRate int     `json:"sample_rate"`
+	Bits       int     `json:"bits"`
+	Frames     int64   `json:"frames"`
+	Duration   float64 `json:"duration"`
+}
+
+// batchResult is the outcome of processing a single file.
+type batchResult struct {
+	Path   string     `json:"path"`
+	Output string     `json:"output,omitempty"`
+	Info   *batchInfo `json:"info,omitempty"`
+	Gain   *float64   `json:"gain,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// batchReport is written after all files have been processed.
+type batchReport struct {
+	Operation string        `json:"operation"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Files     []batchResult `json:"files"`
+}
+
+// batchOp processes the file src and writes the output, if any, to dst.
+type batchOp func(ctx context.Context, dst, src string, res *batchResult) error
+
+// batchCmd applies an operation to all WAVE files in a directory tree.
+func batchCmd(args []string) error {
+	fs := newFlagSet("batch", "info|validate|convert|normalize|tag directory")
+	out := fs.String("o", "", "output `directory` mirroring the input tree")
+	workers := fs.Int("j", runtime.NumCPU(), "number of files processed in parallel")
+	report := fs.String("report", "", "write the JSON report to `file` instead of stdout")
+	bits := fs.Int("bits", 0, "bit depth of converted and normalized files")
+	lufs := fs.Float64("lufs", -16, "target integrated loudness in `LUFS` when normalizing")
+	description := fs.String("description", "", "description of tagged files")
+	originator := fs.String("originator", "", "originator of tagged files")
+	reference := fs.String("reference", "", "originator reference of tagged files")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected an operation and a directory")
+	}
+
+	var op batchOp
+	switch fs.Arg(0) {
+	case "info":
+		op = infoFile
+	case "validate":
+		op = validateFile
+	case "convert":
+		if *bits == 0 {
+			return errors.New("expected a bit depth to convert to")
+		}
+		op = func(ctx context.Context, dst, src string, res *batchResult) error {
+			return convertFile(ctx, dst, src, *bits)
+		}
+	case "normalize":
+		target := normalize.Target{Mode: normalize.Loudness, Level: *lufs}
+		op = func(ctx context.Context, dst, src string, res *batchResult) error {
+			gain, err := normalizeFile(ctx, dst, src, target, *bits)
+			if err == nil {
+				res.Gain = &gain
+			}
+			return err
+		}
+	case "tag":
+		op = func(ctx context.Context, dst, src string, res *batchResult) error {
+			return tagFile(ctx, dst, src, func(b *wave.Bext) {
+				if *description != "" {
+					b.Description = *description
+				}
+				if *originator != "" {
+					b.Originator = *originator
+				}
+				if *reference != "" {
+					b.OriginatorReference = *reference
+				}
+			})
+		}
+	default:
+		fs.Usage()
+		return errors.Errorf("unknown operation %s", fs.Arg(0))
+	}
+	writes := fs.Arg(0) != "info" && fs.Arg(0) != "validate"
+	if writes && *out == "" {
+		return errors.Errorf("expected an output directory to %s files into", fs.Arg(0))
+	}
+	root := fs.Arg(1)
+	if writes {
+		if err := checkOutputDir(*out, root); err != nil {
+			return err
+		}
+	}
+
+	paths, err := findFiles(root)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results := batch(ctx, paths, *workers, func(ctx context.Context, path string) batchResult {
+		res := batchResult{Path: path}
+		var dst string
+		if writes {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				res.Error = err.Error()
+				return res
+			}
+			dst = filepath.Join(*out, rel)
+			if sameFile(dst, path) {
+				res.Error = errors.Errorf("%s would overwrite its input", dst).Error()
+				return res
+			}
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				res.Error = errors.Wrap(err, "could not create output directory").Error()
+				return res
+			}
+			res.Output = dst
+		}
+		if err := op(ctx, dst, path, &res); err != nil {
+			res.Error = err.Error()
+		}
+		return res
+	})
+
+	r := batchReport{Operation: fs.Arg(0), Files: results}
+	for _, res := range results {
+		if res.Error != "" {
+			r.Failed++
+			continue
+		}
+		r.Succeeded++
+	}
+	w := io.Writer(os.Stdout)
+	if *report != "" {
+		f, err := os.Create(*report)
+		if err != nil {
+			return errors.Wrap(err, "could not create report")
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return errors.Wrap(err, "could not write report")
+	}
+	if r.Failed > 0 {
+		return errors.Errorf("%d of %d files failed", r.Failed, len(results))
+	}
+	return nil
+}
+
+// checkOutputDir returns an error if the directory out is the directory tree
+// root or inside of it, where outputs could overwrite inputs.
+func checkOutputDir(out, root string) error {
+	absOut, err := filepath.Abs(out)
+	if err != nil {
+		return errors.Wrapf(err, "could not resolve %s", out)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return errors.Wrapf(err, "could not resolve %s", root)
+	}
+	rel, err := filepath.Rel(absRoot, absOut)
+	if err != nil {
+		return nil
+	}
+	if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.Errorf("output directory %s is inside of %s", out, root)
+	}
+	return nil
+}
+
+// findFiles returns the paths of all WAVE files in the directory tree root.
+func findFiles(root string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && strings.EqualFold(filepath.Ext(path), ".wav") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not walk %s", root)
+	}
+	return paths, nil
+}
+
+// batch calls fn with every path on a pool of workers and returns the results
+// in the order of the paths. Once ctx is done, the remaining paths fail with
+// its error.
+func batch(ctx context.Context, paths []string, workers int, fn func(ctx context.Context, path string) batchResult) []batchResult {
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]batchResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j] = fn(ctx, paths[j])
+			}
+		}()
+	}
+	for i := range paths {
+		if err := ctx.Err(); err != nil {
+			results[i] = batchResult{Path: paths[i], Error: err.Error()}
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			results[i] = batchResult{Path: paths[i], Error: ctx.Err().Error()}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// infoFile reports the format and length of a file.
+func infoFile(ctx context.Context, dst, src string, res *batchResult) error {
+	in, err := openInput(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	frames, err := in.NumFrames()
+	if err != nil {
+		return errors.Wrap(err, "could not count frames")
+	}
+	res.Info = &batchInfo{
+		Channels:   int(in.Format.NumChans),
+		SampleRate: int(in.Format.SampleRate),
+		Bits:       int(in.Format.BitsPerSample),
+		Frames:     frames,
+	}
+	if in.Format.SampleRate > 0 {
+		res.Info.Duration = float64(frames) / float64(in.Format.SampleRate)
+	}
+	return nil
+}
+
+// validateFile reads a file in strict mode.
+func validateFile(ctx context.Context, dst, src string, res *batchResult) error {
+	in, err := openInput(src, wave.Strict(), wave.Limit(wave.DefaultLimits))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if _, err := in.WriteTo(ioutil.Discard); err != nil {
+		return errors.Wrap(err, "could not read samples")
+	}
+	return nil
+}
+
+// convertFile writes the samples of src with another bit depth to dst.
+func convertFile(ctx context.Context, dst, src string, bits int) error {
+	in, err := openInput(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	var opts []wave.WriterOption
+	if bext, err := in.Bext(); err != nil {
+		return errors.Wrap(err, "could not read bext chunk")
+	} else if bext != nil {
+		opts = append(opts, wave.BextChunk(bext))
+	}
+	w, err := createOutput(dst, withBits(in.Format, bits), opts...)
+	if err != nil {
+		return err
+	}
+	if _, err := wave.Convert(ctx, w.Writer, in.Reader, nil); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// tagFile copies src to dst and updates its bext chunk with fn.
+func tagFile(ctx context.Context, dst, src string, fn func(b *wave.Bext)) error {
+	in, err := openInput(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	bext, err := in.Bext()
+	if err != nil {
+		return errors.Wrap(err, "could not read bext chunk")
+	}
+	if bext == nil {
+		bext = &wave.Bext{
+			LoudnessValue:        wave.LoudnessUnset,
+			LoudnessRange:        wave.LoudnessUnset,
+			MaxTruePeakLevel:     wave.LoudnessUnset,
+			MaxMomentaryLoudness: wave.LoudnessUnset,
+			MaxShortTermLoudness: wave.LoudnessUnset,
+		}
+	}
+	fn(bext)
+	cues, err := in.Cues()
+	if err != nil {
+		return errors.Wrap(err, "could not read cue points")
+	}
+	w, err := createOutput(dst, in.Format, wave.BextChunk(bext))
+	if err != nil {
+		return err
+	}
+	w.SetCues(cues)
+	if _, err := io.Copy(w.Writer, in.Reader); err != nil {
+		w.Close()
+		return errors.Wrap(err, "could not copy samples")
+	}
+	return w.Close()
+}
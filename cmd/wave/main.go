@@ -0,0 +1,147 @@
+// Command wave processes WAVE files.
+//
+// Usage:
+//
+//	wave <command> [flags] [files]
+//
+// Run wave <command> -h for the flags of a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bake/wave"
+	"github.com/pkg/errors"
+)
+
+// command is a subcommand of wave.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"normalize", "normalize peak or loudness", normalizeCmd},
+	{"split", "split a file into one file per channel", splitCmd},
+	{"join", "join files into one file with all their channels", joinCmd},
+	{"batch", "process all files in a directory tree", batchCmd},
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("wave: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name != flag.Arg(0) {
+			continue
+		}
+		if err := cmd.run(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	log.Printf("unknown command %s", flag.Arg(0))
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: wave <command> [flags] [files]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// newFlagSet creates the flags of a command.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: wave %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// input is an opened WAVE file.
+type input struct {
+	*wave.Reader
+	f *os.File
+}
+
+// openInput opens a WAVE file for reading.
+func openInput(path string, opts ...wave.ReaderOption) (*input, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open file")
+	}
+	wavr, err := wave.NewReader(f, opts...)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "could not read %s", path)
+	}
+	return &input{wavr, f}, nil
+}
+
+// Close closes the file.
+func (in *input) Close() error {
+	return in.f.Close()
+}
+
+// sameFile reports if both paths refer to the same existing file.
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
+
+// output is a WAVE file that is being written.
+type output struct {
+	*wave.Writer
+	f *os.File
+}
+
+// createOutput creates a WAVE file.
+func createOutput(path string, format wave.Format, opts ...wave.WriterOption) (*output, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create file")
+	}
+	wavw, err := wave.NewWriter(f, format, opts...)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "could not write %s", path)
+	}
+	return &output{Writer: wavw, f: f}, nil
+}
+
+// Close closes the writer and the file.
+func (out *output) Close() error {
+	if err := out.Writer.Close(); err != nil {
+		out.f.Close()
+		return errors.Wrap(err, "could not close wave writer")
+	}
+	return errors.Wrap(out.f.Close(), "could not close file")
+}
+
+// withBits returns the format with another bit depth.
+func withBits(format wave.Format, bits int) wave.Format {
+	format.BitsPerSample = uint16(bits)
+	format.BlockAlign = format.NumChans * uint16((bits+7)/8)
+	format.ByteRate = format.SampleRate * uint32(format.BlockAlign)
+	return format
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bake/wave"
+)
+
+func TestWithBits(t *testing.T) {
+	tt := []struct {
+		chans, bits int
+		exp         string
+	}{
+		{1, 8, "1 44100"},
+		{2, 8, "2 88200"},
+		{2, 12, "4 176400"},
+		{2, 16, "4 176400"},
+		{2, 24, "6 264600"},
+		{6, 32, "24 1058400"},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("%d channels %d bits", tc.chans, tc.bits), func(t *testing.T) {
+			format := withBits(wave.Format{
+				AudioFormat:   1,
+				NumChans:      uint16(tc.chans),
+				SampleRate:    44100,
+				ByteRate:      44100 * uint32(tc.chans) * 3,
+				BlockAlign:    uint16(tc.chans) * 3,
+				BitsPerSample: 24,
+			}, tc.bits)
+			if got := fmt.Sprint(format.BlockAlign, format.ByteRate); got != tc.exp {
+				t.Fatalf("expected block align and byte rate to be %s, got %s", tc.exp, got)
+			}
+			if err := format.Validate(); err != nil {
+				t.Fatalf("expected format to be valid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	paths := []string{"a.wav", "b.wav", "c.wav", "d.wav"}
+	results := batch(ctx, paths, 1, func(ctx context.Context, path string) batchResult {
+		cancel()
+		return batchResult{Path: path}
+	})
+	var failed []string
+	for _, res := range results {
+		if res.Error != "" {
+			failed = append(failed, res.Path)
+		}
+	}
+	if len(failed) < 2 {
+		t.Fatalf("expected the files after the cancellation to fail, got %v", results)
+	}
+	for _, res := range results[len(paths)-len(failed):] {
+		if res.Error != context.Canceled.Error() {
+			t.Fatalf("expected %s to fail with %v, got %q", res.Path, context.Canceled, res.Error)
+		}
+	}
+}
+
+// writeFile writes a file of 16 bit stereo samples to path.
+func writeFile(t *testing.T, path string, samples []int) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+	defer f.Close()
+	wavw, err := wave.NewWriter(f, withBits(wave.Format{AudioFormat: 1, NumChans: 2, SampleRate: 8000}, 16))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples(samples); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+}
+
+func TestBatchOutputDir(t *testing.T) {
+	root := t.TempDir()
+	tt := []struct {
+		out string
+		ok  bool
+	}{
+		{root, false},
+		{filepath.Join(root, "out"), false},
+		{filepath.Join(root, "a", ".."), false},
+		{filepath.Join(root, ".."), true},
+		{root + "-out", true},
+	}
+	for _, tc := range tt {
+		if err := checkOutputDir(tc.out, root); (err == nil) != tc.ok {
+			t.Fatalf("expected output directory %s to be allowed: %v, got %v", tc.out, tc.ok, err)
+		}
+	}
+
+	path := filepath.Join(root, "in.wav")
+	writeFile(t, path, []int{1, 2, 3, 4})
+	before, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	if err := batchCmd([]string{"-o", root, "-bits", "8", "convert", root}); err == nil {
+		t.Fatalf("expected converting into the input directory to fail")
+	}
+	after, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("expected input to be unchanged")
+	}
+}
+
+func TestSameFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.wav")
+	writeFile(t, path, []int{1, 2})
+	link := filepath.Join(dir, "link.wav")
+	if err := os.Link(path, link); err != nil {
+		t.Fatalf("could not link file: %v", err)
+	}
+	if !sameFile(path, filepath.Join(dir, ".", "in.wav")) || !sameFile(path, link) {
+		t.Fatalf("expected paths to refer to the same file")
+	}
+	if sameFile(path, filepath.Join(dir, "out.wav")) {
+		t.Fatalf("expected a missing file not to be the same")
+	}
+}
+
+func TestNormalizeSameFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.wav")
+	writeFile(t, path, []int{1000, -1000, 2000, -2000})
+	before, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	if err := normalizeCmd([]string{"-o", path, path}); err == nil {
+		t.Fatalf("expected normalizing a file into itself to fail")
+	}
+	after, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("expected input to be unchanged")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/normalize"
+	"github.com/pkg/errors"
+)
+
+// normalizeCmd normalizes a file to a target peak or loudness.
+func normalizeCmd(args []string) error {
+	fs := newFlagSet("normalize", "input")
+	out := fs.String("o", "", "output `file`")
+	peak := fs.Float64("peak", -1, "target sample peak in `dBFS`")
+	truePeak := fs.Float64("truepeak", -1, "target true peak in `dBTP`")
+	lufs := fs.Float64("lufs", -16, "target integrated loudness in `LUFS`")
+	bits := fs.Int("bits", 0, "bit depth of the output, defaults to the input's")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *out == "" {
+		fs.Usage()
+		return errors.New("expected an input and an output file")
+	}
+	target := normalize.Target{Mode: normalize.Loudness, Level: *lufs}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "peak":
+			target = normalize.Target{Mode: normalize.Peak, Level: *peak}
+		case "truepeak":
+			target = normalize.Target{Mode: normalize.TruePeak, Level: *truePeak}
+		}
+	})
+
+	gain, err := normalizeFile(context.Background(), *out, fs.Arg(0), target, *bits)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: applied %+.2f dB\n", fs.Arg(0), gain)
+	return nil
+}
+
+// normalizeFile normalizes the file src into dst and returns the applied gain.
+// If bits is not 0, dst is written with another bit depth. dst has to be
+// another file than src.
+func normalizeFile(ctx context.Context, dst, src string, target normalize.Target, bits int) (float64, error) {
+	if sameFile(dst, src) {
+		return 0, errors.Errorf("%s would overwrite its input", dst)
+	}
+	in, err := openInput(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	format := in.Format
+	var opts []wave.WriterOption
+	if bits > 0 && bits != int(format.BitsPerSample) {
+		if bits < int(format.BitsPerSample) {
+			opts = append(opts, wave.Dither())
+		}
+		format = withBits(format, bits)
+	}
+	if bext, err := in.Bext(); err != nil {
+		return 0, errors.Wrap(err, "could not read bext chunk")
+	} else if bext != nil {
+		// The measured loudness does not apply to the output.
+		bext.LoudnessValue = wave.LoudnessUnset
+		bext.LoudnessRange = wave.LoudnessUnset
+		bext.MaxTruePeakLevel = wave.LoudnessUnset
+		bext.MaxMomentaryLoudness = wave.LoudnessUnset
+		bext.MaxShortTermLoudness = wave.LoudnessUnset
+		opts = append(opts, wave.BextChunk(bext))
+	}
+	if err := in.Rewind(); err != nil {
+		return 0, err
+	}
+	w, err := createOutput(dst, format, opts...)
+	if err != nil {
+		return 0, err
+	}
+	gain, err := normalize.Apply(ctx, w.Writer, in.Reader, target)
+	if err != nil {
+		w.Close()
+		return 0, err
+	}
+	return gain, w.Close()
+}
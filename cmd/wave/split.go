@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/mix"
+	"github.com/pkg/errors"
+)
+
+// splitCmd splits a file into one file per channel.
+func splitCmd(args []string) error {
+	fs := newFlagSet("split", "input")
+	dir := fs.String("o", "", "output `directory`, defaults to the input's")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected an input file")
+	}
+	in, err := openInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	bext, err := in.Bext()
+	if err != nil {
+		return errors.Wrap(err, "could not read bext chunk")
+	}
+	var opts []wave.WriterOption
+	if bext != nil {
+		opts = append(opts, wave.BextChunk(bext))
+	}
+	if *dir == "" {
+		*dir = filepath.Dir(fs.Arg(0))
+	}
+	base := strings.TrimSuffix(filepath.Base(fs.Arg(0)), filepath.Ext(fs.Arg(0)))
+	outs := make([]*output, in.Format.NumChans)
+	dsts := make([]*wave.Writer, in.Format.NumChans)
+	for c := range outs {
+		path := filepath.Join(*dir, fmt.Sprintf("%s_%d.wav", base, c+1))
+		if outs[c], err = createOutput(path, mix.ChannelFormat(in.Format, c), opts...); err != nil {
+			closeOutputs(outs)
+			return err
+		}
+		dsts[c] = outs[c].Writer
+	}
+	if err := mix.Split(context.Background(), dsts, in.Reader); err != nil {
+		closeOutputs(outs)
+		return err
+	}
+	return closeOutputs(outs)
+}
+
+// joinCmd joins files into one file with all their channels.
+func joinCmd(args []string) error {
+	fs := newFlagSet("join", "inputs...")
+	out := fs.String("o", "", "output `file`")
+	fs.Parse(args)
+	if fs.NArg() == 0 || *out == "" {
+		fs.Usage()
+		return errors.New("expected input files and an output file")
+	}
+	var bext *wave.Bext
+	var formats []wave.Format
+	var srcs []*wave.Reader
+	for _, path := range fs.Args() {
+		in, err := openInput(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		b, err := in.Bext()
+		if err != nil {
+			return errors.Wrapf(err, "could not read bext chunk of %s", path)
+		}
+		if bext == nil && b != nil {
+			first := *b
+			bext = &first
+		}
+		formats = append(formats, in.Format)
+		srcs = append(srcs, in.Reader)
+	}
+	format, err := mix.JoinFormat(formats...)
+	if err != nil {
+		return err
+	}
+	var opts []wave.WriterOption
+	if bext != nil {
+		opts = append(opts, wave.BextChunk(bext))
+	}
+	w, err := createOutput(*out, format, opts...)
+	if err != nil {
+		return err
+	}
+	start, err := mix.Join(context.Background(), w.Writer, srcs)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	if bext != nil {
+		bext.TimeReference = start
+	}
+	return w.Close()
+}
+
+// closeOutputs closes all outputs and returns the first error.
+func closeOutputs(outs []*output) error {
+	var first error
+	for _, out := range outs {
+		if out == nil {
+			continue
+		}
+		if err := out.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
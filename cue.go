@@ -0,0 +1,113 @@
+package wave
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/bake/wave/riff"
+	"github.com/pkg/errors"
+)
+
+// Cue is a marked position in the samples.
+type Cue struct {
+	ID    uint32
+	Frame int64
+}
+
+// cuePoint is the binary layout of a cue point.
+type cuePoint struct {
+	ID           uint32
+	Position     uint32
+	DataChunkID  [4]byte
+	ChunkStart   uint32
+	BlockStart   uint32
+	SampleOffset uint32
+}
+
+// decodeCues decodes a cue chunk.
+func decodeCues(r io.Reader) ([]Cue, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	var cues []Cue
+	for i := uint32(0); i < n; i++ {
+		var p cuePoint
+		if err := binary.Read(r, binary.LittleEndian, &p); err != nil {
+			return nil, err
+		}
+		cues = append(cues, Cue{ID: p.ID, Frame: int64(p.SampleOffset)})
+	}
+	return cues, nil
+}
+
+// encodeCues encodes a cue chunk whose points refer to the first data chunk.
+func encodeCues(w io.Writer, cues []Cue) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(cues))); err != nil {
+		return err
+	}
+	for _, c := range cues {
+		p := cuePoint{
+			ID:           c.ID,
+			Position:     uint32(c.Frame),
+			DataChunkID:  [4]byte{'d', 'a', 't', 'a'},
+			SampleOffset: uint32(c.Frame),
+		}
+		if err := binary.Write(w, binary.LittleEndian, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cues returns the cue points of the file. Cue chunks usually follow the
+// samples, so this requires the underlying reader to be an io.Seeker. The
+// position of the reader is not changed.
+func (wavr *Reader) Cues() ([]Cue, error) {
+	var cues []Cue
+	err := wavr.scan(func(rr *riff.Reader) error {
+		for rr.Next() {
+			id, _, _ := rr.Chunk()
+			if id == "cue " {
+				data, err := wavr.buffer(rr)
+				if err != nil {
+					return err
+				}
+				c, err := decodeCues(data)
+				if err != nil {
+					return errors.Wrap(err, "could not decode cue chunk")
+				}
+				cues = append(cues, c...)
+			}
+			if err := rr.Skip(); err != nil {
+				return errors.Wrapf(err, "could not skip %s chunk", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cues, nil
+}
+
+// SetCues sets the cue points that are written after the samples when the
+// writer is closed.
+func (wavw *Writer) SetCues(cues []Cue) {
+	wavw.cues = cues
+}
+
+// writeCues writes the cue chunk, if there are any cue points.
+func (wavw *Writer) writeCues() error {
+	if len(wavw.cues) == 0 {
+		return nil
+	}
+	cw, err := wavw.rw.Chunk("cue ")
+	if err != nil {
+		return errors.Wrap(err, "could not create cue chunk")
+	}
+	if err := encodeCues(cw, wavw.cues); err != nil {
+		return errors.Wrap(err, "could not encode cue chunk")
+	}
+	return errors.Wrap(cw.Close(), "could not close cue chunk")
+}
@@ -0,0 +1,168 @@
+package wave_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+)
+
+func TestCues(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      1,
+		SampleRate:    8000,
+		ByteRate:      8000,
+		BlockAlign:    1,
+		BitsPerSample: 8,
+	}
+	cues := []wave.Cue{{ID: 1, Frame: 0}, {ID: 2, Frame: 3}}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples([]int{1, 2, 3, 4, 5}); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	wavw.SetCues(cues)
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	out := []byte{
+		// R,    I,    F,    F,                    102,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x66, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+
+		// f,    m,    t,    ␣,                     16,          1,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		//                8000,                   8000,          1,          8,
+		0x40, 0x1f, 0x00, 0x00, 0x40, 0x1f, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00,
+
+		// d,    a,    t,    a,                      5,    1,    2,    3,    4,
+		0x64, 0x61, 0x74, 0x61, 0x05, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		//   5,  pad,
+		0x05, 0x00,
+
+		// c,    u,    e,    ␣,                     52,                      2,
+		0x63, 0x75, 0x65, 0x20, 0x34, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+		//                   1,                      0,    d,    a,    t,    a,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x64, 0x61, 0x74, 0x61,
+		//                   0,                      0,                      0,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		//                   2,                      3,    d,    a,    t,    a,
+		0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x64, 0x61, 0x74, 0x61,
+		//                   0,                      0,                      3,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00,
+	}
+	body := new(bytes.Buffer)
+	if _, err := io.Copy(body, ws.Reader()); err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	if fmt.Sprintf("% x", body.Bytes()) != fmt.Sprintf("% x", out) {
+		t.Fatalf("expected body to be\n% x, got\n% x\n", out, body.Bytes())
+	}
+
+	wavr, err := wave.NewReader(bytes.NewReader(out), wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	got, err := wavr.Cues()
+	if err != nil {
+		t.Fatalf("could not read cues: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(cues) {
+		t.Fatalf("expected cues to be %v, got %v", cues, got)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if fmt.Sprint(samples) != "[1 2 3 4 5]" {
+		t.Fatalf("expected samples to be [1 2 3 4 5], got %v", samples)
+	}
+}
+
+func TestCuesTrailingBytes(t *testing.T) {
+	data := []byte{
+		// R,    I,    F,    F,                    118,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x76, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+
+		// f,    m,    t,    ␣,                     16,          1,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		//                8000,                   8000,          1,          8,
+		0x40, 0x1f, 0x00, 0x00, 0x40, 0x1f, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00,
+
+		// d,    a,    t,    a,                      5,    1,    2,    3,    4,
+		0x64, 0x61, 0x74, 0x61, 0x05, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		//   5,  pad,
+		0x05, 0x00,
+
+		// c,    u,    e,    ␣,                     31,                      1,
+		0x63, 0x75, 0x65, 0x20, 0x1f, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		//                   1,                      0,    d,    a,    t,    a,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x64, 0x61, 0x74, 0x61,
+		//                   0,                      0,                      0,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		// trailing bytes,  pad,
+		0xaa, 0xbb, 0xcc, 0x00,
+
+		// c,    u,    e,    ␣,                     28,                      1,
+		0x63, 0x75, 0x65, 0x20, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		//                   2,                      3,    d,    a,    t,    a,
+		0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x64, 0x61, 0x74, 0x61,
+		//                   0,                      0,                      3,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00,
+	}
+	wavr, err := wave.NewReader(bytes.NewReader(data), wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	cues, err := wavr.Cues()
+	if err != nil {
+		t.Fatalf("could not read cues: %v", err)
+	}
+	if exp := "[{1 0} {2 3}]"; fmt.Sprint(cues) != exp {
+		t.Fatalf("expected cues to be %s, got %v", exp, cues)
+	}
+}
+
+func TestCuesError(t *testing.T) {
+	data := []byte{
+		// R,    I,    F,    F,                     78,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x4e, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+
+		// f,    m,    t,    ␣,                     16,          1,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		//                8000,                   8000,          1,          8,
+		0x40, 0x1f, 0x00, 0x00, 0x40, 0x1f, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00,
+
+		// d,    a,    t,    a,                      5,    1,    2,    3,    4,
+		0x64, 0x61, 0x74, 0x61, 0x05, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		//   5,  pad,
+		0x05, 0x00,
+
+		// c,    u,    e,    ␣,                     28,                      2,
+		0x63, 0x75, 0x65, 0x20, 0x1c, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+		//                   1,                      0,    d,    a,    t,    a,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x64, 0x61, 0x74, 0x61,
+		//                   0,                      0,                      0,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	wavr, err := wave.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if _, err := wavr.Cues(); err == nil {
+		t.Fatalf("expected an error for a truncated cue chunk")
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if fmt.Sprint(samples) != "[1 2 3 4 5]" {
+		t.Fatalf("expected samples to be [1 2 3 4 5], got %v", samples)
+	}
+}
@@ -0,0 +1,207 @@
+// Package edit cuts, trims and concatenates WAVE files. Samples are copied
+// without decoding them, cue points and the time reference of bext chunks are
+// adjusted to the new positions.
+package edit
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/bake/wave"
+	"github.com/pkg/errors"
+)
+
+// Cut writes the frames from start up to end of src into a new file. An end
+// of -1 cuts until the end of src.
+func Cut(ctx context.Context, ws io.WriteSeeker, src *wave.Reader, start, end int64) error {
+	if start < 0 || (end >= 0 && end < start) {
+		return errors.Errorf("invalid range from %d to %d", start, end)
+	}
+	bext, cues, err := metadata(src)
+	if err != nil {
+		return err
+	}
+	if bext != nil {
+		bext.TimeReference += uint64(start)
+	}
+	dst, err := newWriter(ws, src.Format, bext)
+	if err != nil {
+		return err
+	}
+	dst.SetCues(ShiftCues(cues, start, end, 0))
+	if _, err := copyFrames(ctx, ioutil.Discard, src, start); err != nil {
+		return errors.Wrap(err, "could not skip frames")
+	}
+	frames := int64(-1)
+	if end >= 0 {
+		frames = end - start
+	}
+	if _, err := copyFrames(ctx, dst, src, frames); err != nil {
+		return errors.Wrap(err, "could not copy frames")
+	}
+	return errors.Wrap(dst.Close(), "could not close wave writer")
+}
+
+// Trim writes src without leading and trailing frames below threshold dBFS
+// into a new file. src is read twice and has to be seekable. It returns the
+// range of frames that has been kept.
+func Trim(ctx context.Context, ws io.WriteSeeker, src *wave.Reader, threshold float64) (int64, int64, error) {
+	start, end, err := Bounds(ctx, src, threshold)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := src.Rewind(); err != nil {
+		return 0, 0, errors.Wrap(err, "could not rewind")
+	}
+	return start, end, Cut(ctx, ws, src, start, end)
+}
+
+// Bounds reads all samples from src and returns the range of frames from the
+// first to the last one with a sample of at least threshold dBFS. Both are 0
+// if all frames are below.
+func Bounds(ctx context.Context, src *wave.Reader, threshold float64) (int64, int64, error) {
+	level := math.Pow(10, threshold/20)
+	chans := int(src.Format.NumChans)
+	start, end := int64(-1), int64(0)
+	var frame int64
+	err := wave.ReadBlocks(ctx, src, func(block []float64) error {
+		for i := 0; i+chans <= len(block); i += chans {
+			for _, v := range block[i : i+chans] {
+				if math.Abs(v) >= level {
+					if start < 0 {
+						start = frame
+					}
+					end = frame + 1
+					break
+				}
+			}
+			frame++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if start < 0 {
+		return 0, 0, nil
+	}
+	return start, end, nil
+}
+
+// Concat writes all frames of srcs one after another into a new file. All
+// sources have to share the same format. The bext chunk of the first source is
+// kept and cue points of all sources are renumbered.
+func Concat(ctx context.Context, ws io.WriteSeeker, srcs ...*wave.Reader) error {
+	if len(srcs) == 0 {
+		return errors.New("no files to concatenate")
+	}
+	format := srcs[0].Format
+	for i, src := range srcs[1:] {
+		if src.Format != format {
+			return errors.Errorf("format of file %d does not match the first one", i+1)
+		}
+	}
+	bext, _, err := metadata(srcs[0])
+	if err != nil {
+		return err
+	}
+	dst, err := newWriter(ws, format, bext)
+	if err != nil {
+		return err
+	}
+	var cues []wave.Cue
+	var offset int64
+	for i, src := range srcs {
+		_, c, err := metadata(src)
+		if err != nil {
+			return err
+		}
+		frames, err := copyFrames(ctx, dst, src, -1)
+		if err != nil {
+			return errors.Wrapf(err, "could not copy frames of file %d", i)
+		}
+		cues = append(cues, ShiftCues(c, 0, -1, offset)...)
+		offset += frames
+	}
+	for i := range cues {
+		cues[i].ID = uint32(i + 1)
+	}
+	dst.SetCues(cues)
+	return errors.Wrap(dst.Close(), "could not close wave writer")
+}
+
+// ShiftCues returns the cue points between start and end, an end of -1 being
+// unbounded, moved to begin at offset.
+func ShiftCues(cues []wave.Cue, start, end, offset int64) []wave.Cue {
+	var shifted []wave.Cue
+	for _, c := range cues {
+		if c.Frame < start || (end >= 0 && c.Frame >= end) {
+			continue
+		}
+		c.Frame += offset - start
+		shifted = append(shifted, c)
+	}
+	return shifted
+}
+
+// metadata returns the bext chunk and the cue points of src. Cue points are
+// only available if src is seekable.
+func metadata(src *wave.Reader) (*wave.Bext, []wave.Cue, error) {
+	bext, err := src.Bext()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read bext chunk")
+	}
+	cues, err := src.Cues()
+	if err == wave.ErrNotSeekable {
+		err = nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read cues")
+	}
+	if bext != nil {
+		b := *bext
+		bext = &b
+	}
+	return bext, cues, nil
+}
+
+// newWriter creates a wave writer with an optional bext chunk.
+func newWriter(ws io.WriteSeeker, format wave.Format, bext *wave.Bext) (*wave.Writer, error) {
+	var opts []wave.WriterOption
+	if bext != nil {
+		opts = append(opts, wave.BextChunk(bext))
+	}
+	wavw, err := wave.NewWriter(ws, format, opts...)
+	return wavw, errors.Wrap(err, "could not create wave writer")
+}
+
+// copyFrames copies frames from src to dst until EOF or until ctx is done. It
+// copies all remaining frames if frames is -1 and returns the number of frames
+// copied.
+func copyFrames(ctx context.Context, dst io.Writer, src *wave.Reader, frames int64) (int64, error) {
+	if err := src.Format.CheckFrames(); err != nil {
+		return 0, err
+	}
+	frameSize := int64(src.Format.BlockAlign)
+	var total int64
+	for frames < 0 || total < frames {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n := int64(wave.BlockSize)
+		if frames >= 0 && frames-total < n {
+			n = frames - total
+		}
+		written, err := io.CopyN(dst, src, n*frameSize)
+		total += written / frameSize
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
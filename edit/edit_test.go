@@ -0,0 +1,122 @@
+package edit_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/edit"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+var format = wavetest.Format(2, 8000, 16)
+
+// write writes samples, cues and a bext chunk into a new file and returns a
+// reader of it.
+func write(t *testing.T, samples []int, cues []wave.Cue, bext *wave.Bext) *wave.Reader {
+	var opts []wave.WriterOption
+	if bext != nil {
+		opts = append(opts, wave.BextChunk(bext))
+	}
+	ws := wavetest.Write(t, format, func(wavw *wave.Writer) error {
+		wavw.SetCues(cues)
+		return wavw.Samples(samples)
+	}, opts...)
+	return wavetest.Read(t, ws, wave.Strict())
+}
+
+// contents returns the samples, cues and time reference of a file.
+func contents(t *testing.T, wavr *wave.Reader) string {
+	cues, err := wavr.Cues()
+	if err != nil {
+		t.Fatalf("could not read cues: %v", err)
+	}
+	bext, err := wavr.Bext()
+	if err != nil {
+		t.Fatalf("could not read bext chunk: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	ref := "-"
+	if bext != nil {
+		ref = fmt.Sprint(bext.TimeReference)
+	}
+	return fmt.Sprintf("%v %v %s", samples, cues, ref)
+}
+
+func TestCut(t *testing.T) {
+	samples := []int{0, 0, 1, -1, 2, -2, 3, -3, 4, -4}
+	cues := []wave.Cue{{ID: 1, Frame: 0}, {ID: 2, Frame: 2}, {ID: 3, Frame: 4}}
+	tt := []struct {
+		start, end int64
+		out        string
+	}{
+		{1, 4, "[1 -1 2 -2 3 -3] [{2 1}] 1001"},
+		{3, -1, "[3 -3 4 -4] [{3 1}] 1003"},
+		{0, 0, "[] [] 1000"},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprint(tc.start, tc.end), func(t *testing.T) {
+			src := write(t, samples, cues, &wave.Bext{TimeReference: 1000})
+			ws := &writerseeker.WriterSeeker{}
+			if err := edit.Cut(context.Background(), ws, src, tc.start, tc.end); err != nil {
+				t.Fatalf("could not cut file: %v", err)
+			}
+			if got := contents(t, wavetest.Read(t, ws, wave.Strict())); got != tc.out {
+				t.Fatalf("expected %s, got %s", tc.out, got)
+			}
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	samples := []int{0, 1, 2, 0, 0, -300, 5, 6, 400, 0, 0, 2, 0, 0}
+	src := write(t, samples, nil, nil)
+	ws := &writerseeker.WriterSeeker{}
+	start, end, err := edit.Trim(context.Background(), ws, src, -50)
+	if err != nil {
+		t.Fatalf("could not trim file: %v", err)
+	}
+	if start != 2 || end != 5 {
+		t.Fatalf("expected frames 2 to 5 to be kept, got %d to %d", start, end)
+	}
+	if got, exp := contents(t, wavetest.Read(t, ws, wave.Strict())), "[0 -300 5 6 400 0] [] -"; got != exp {
+		t.Fatalf("expected %s, got %s", exp, got)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	srcs := []*wave.Reader{
+		write(t, []int{1, 2, 3, 4}, []wave.Cue{{ID: 7, Frame: 1}}, &wave.Bext{TimeReference: 10}),
+		write(t, []int{5, 6}, []wave.Cue{{ID: 7, Frame: 0}}, &wave.Bext{TimeReference: 20}),
+		write(t, []int{7, 8, 9, 10}, nil, nil),
+	}
+	ws := &writerseeker.WriterSeeker{}
+	if err := edit.Concat(context.Background(), ws, srcs...); err != nil {
+		t.Fatalf("could not concatenate files: %v", err)
+	}
+	exp := "[1 2 3 4 5 6 7 8 9 10] [{1 1} {2 2}] 10"
+	if got := contents(t, wavetest.Read(t, ws, wave.Strict())); got != exp {
+		t.Fatalf("expected %s, got %s", exp, got)
+	}
+
+	mono := wavetest.Samples(t, wavetest.Format(1, 8000, 16), nil)
+	srcs = []*wave.Reader{write(t, nil, nil, nil), mono}
+	if err := edit.Concat(context.Background(), &writerseeker.WriterSeeker{}, srcs...); err == nil {
+		t.Fatalf("expected files with different formats not to be concatenated")
+	}
+}
+
+func TestNoChannels(t *testing.T) {
+	if _, _, err := edit.Bounds(context.Background(), wavetest.NoChannels(t), -50); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+	if err := edit.Cut(context.Background(), &writerseeker.WriterSeeker{}, wavetest.NoChannels(t), 0, -1); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
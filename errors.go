@@ -0,0 +1,39 @@
+package wave
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Errors returned by Format.Validate and by a strict Reader. They are usually
+// wrapped in a *ValidationError and can be tested for with errors.Is.
+var (
+	ErrUnsupportedFormat      = errors.New("unsupported format")
+	ErrInconsistentBlockAlign = errors.New("inconsistent block align")
+	ErrInconsistentByteRate   = errors.New("inconsistent byte rate")
+	ErrTruncatedChunk         = errors.New("truncated chunk")
+	ErrMissingDataChunk       = errors.New("missing data chunk")
+)
+
+// ErrNotSeekable is returned by operations that require the underlying reader
+// to be an io.Seeker.
+var ErrNotSeekable = errors.New("reader is not seekable")
+
+// ValidationError describes a structural problem in a WAVE file and where it
+// has been found.
+type ValidationError struct {
+	Offset int64  // Offset of the chunk header relative to the RIFF header.
+	Chunk  string // ID of the offending chunk, if any.
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Chunk == "" {
+		return fmt.Sprintf("offset %d: %v", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("%s chunk at offset %d: %v", e.Chunk, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error { return e.Err }
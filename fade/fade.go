@@ -0,0 +1,227 @@
+// Package fade applies fades and crossfades to WAVE files.
+package fade
+
+import (
+	"context"
+	"io"
+	"math"
+
+	"github.com/bake/wave"
+	"github.com/pkg/errors"
+)
+
+// Curve is the shape of a fade.
+type Curve int
+
+// Curves of a fade.
+const (
+	Linear      Curve = iota // Constant change of amplitude.
+	EqualPower               // Constant power in crossfades.
+	Logarithmic              // Constant change of level in dB, from -60 dB.
+)
+
+// logRange is the range of a logarithmic fade in dB.
+const logRange = 60
+
+// Gain returns the gain of a fade in at position x between 0 and 1.
+func (c Curve) Gain(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	switch c {
+	case EqualPower:
+		return math.Sin(x * math.Pi / 2)
+	case Logarithmic:
+		return math.Pow(10, -logRange*(1-x)/20)
+	}
+	return x
+}
+
+// Fade describes a fade of a number of frames.
+type Fade struct {
+	Frames int64
+	Curve  Curve
+}
+
+// in returns the gain of frame i of a fade in.
+func (f Fade) in(i int64) float64 {
+	if i >= f.Frames {
+		return 1
+	}
+	return f.Curve.Gain(float64(i) / float64(f.Frames))
+}
+
+// out returns the gain of frame i of a fade out.
+func (f Fade) out(i int64) float64 {
+	if i >= f.Frames {
+		return 0
+	}
+	return f.Curve.Gain(float64(f.Frames-i) / float64(f.Frames))
+}
+
+// Fader fades interleaved samples in and out.
+type Fader struct {
+	chans   int
+	total   int64 // Number of frames, -1 if unknown.
+	in, out Fade
+	frame   int64
+}
+
+// NewFader creates a fader of chans channels. Fading out requires the total
+// number of frames.
+func NewFader(chans int, total int64, in, out Fade) (*Fader, error) {
+	if out.Frames > 0 && total < 0 {
+		return nil, errors.New("can not fade out without the number of frames")
+	}
+	if total >= 0 && in.Frames+out.Frames > total {
+		return nil, errors.Errorf("fades of %d frames exceed %d frames", in.Frames+out.Frames, total)
+	}
+	return &Fader{chans: chans, total: total, in: in, out: out}, nil
+}
+
+// Process fades samples in place. Incomplete frames are ignored.
+func (f *Fader) Process(samples []float64) {
+	for i := 0; i+f.chans <= len(samples); i += f.chans {
+		gain := f.in.in(f.frame)
+		if start := f.total - f.out.Frames; f.out.Frames > 0 && f.frame >= start {
+			gain *= f.out.out(f.frame - start)
+		}
+		for c := range samples[i : i+f.chans] {
+			samples[i+c] *= gain
+		}
+		f.frame++
+	}
+}
+
+// Apply reads all samples from src until EOF or until ctx is done, fades them
+// in and out and writes them to dst. Fading out requires src to be seekable.
+func Apply(ctx context.Context, dst *wave.Writer, src *wave.Reader, in, out Fade) error {
+	if err := src.Format.CheckFrames(); err != nil {
+		return err
+	}
+	total := int64(-1)
+	if out.Frames > 0 {
+		var err error
+		if total, err = src.NumFrames(); err != nil {
+			return errors.Wrap(err, "could not count frames")
+		}
+	}
+	f, err := NewFader(int(src.Format.NumChans), total, in, out)
+	if err != nil {
+		return err
+	}
+	return wave.ReadBlocks(ctx, src, func(block []float64) error {
+		f.Process(block)
+		if err := dst.Floats(block); err != nil {
+			return errors.Wrap(err, "could not write samples")
+		}
+		return nil
+	})
+}
+
+// Crossfade writes all srcs one after another into dst, overlapping the end of
+// each one with the beginning of the next. All sources have to share the same
+// number of channels and sample rate and have to be seekable.
+func Crossfade(ctx context.Context, dst *wave.Writer, fade Fade, srcs ...*wave.Reader) error {
+	if len(srcs) == 0 {
+		return errors.New("no files to crossfade")
+	}
+	chans := int(srcs[0].Format.NumChans)
+	for i, src := range srcs {
+		if err := src.Format.CheckFrames(); err != nil {
+			return errors.Wrapf(err, "file %d", i)
+		}
+		if src.Format.NumChans != srcs[0].Format.NumChans || src.Format.SampleRate != srcs[0].Format.SampleRate {
+			return errors.Errorf("format of file %d does not match the first one", i)
+		}
+	}
+	totals := make([]int64, len(srcs))
+	for i, src := range srcs {
+		var err error
+		if totals[i], err = src.NumFrames(); err != nil {
+			return errors.Wrapf(err, "could not count frames of file %d", i)
+		}
+		if totals[i] < 0 {
+			return errors.Wrapf(wave.ErrNotSeekable, "file %d", i)
+		}
+		// Inner files are overlapped at both ends.
+		overlap := fade.Frames
+		if i > 0 && i < len(srcs)-1 {
+			overlap *= 2
+		}
+		if len(srcs) > 1 && totals[i] < overlap {
+			return errors.Errorf("file %d is shorter than the crossfade", i)
+		}
+	}
+	// tail holds the faded out end of the previous file.
+	var tail []float64
+	for i, src := range srcs {
+		rest := totals[i]
+		if i > 0 {
+			head := make([]float64, len(tail))
+			if err := readFull(src, head); err != nil {
+				return errors.Wrapf(err, "could not read file %d", i)
+			}
+			for j := range head {
+				k := int64(j / chans)
+				head[j] = head[j]*fade.in(k) + tail[j]*fade.out(k)
+			}
+			if err := dst.Floats(head); err != nil {
+				return errors.Wrap(err, "could not write samples")
+			}
+			rest -= fade.Frames
+		}
+		if i < len(srcs)-1 {
+			rest -= fade.Frames
+		}
+		if err := copyFloats(ctx, dst, src, rest*int64(chans)); err != nil {
+			return errors.Wrapf(err, "could not copy file %d", i)
+		}
+		if i < len(srcs)-1 {
+			tail = make([]float64, fade.Frames*int64(chans))
+			if err := readFull(src, tail); err != nil {
+				return errors.Wrapf(err, "could not read file %d", i)
+			}
+		}
+	}
+	return nil
+}
+
+// readFull reads exactly len(buf) samples.
+func readFull(src *wave.Reader, buf []float64) error {
+	for len(buf) > 0 {
+		n, err := src.Floats(buf)
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		buf = buf[n:]
+	}
+	return nil
+}
+
+// copyFloats copies n samples from src to dst.
+func copyFloats(ctx context.Context, dst *wave.Writer, src *wave.Reader, n int64) error {
+	buf := make([]float64, wave.BlockSize*int(src.Format.NumChans))
+	for n > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if int64(len(buf)) > n {
+			buf = buf[:n]
+		}
+		if err := readFull(src, buf); err != nil {
+			return err
+		}
+		if err := dst.Floats(buf); err != nil {
+			return errors.Wrap(err, "could not write samples")
+		}
+		n -= int64(len(buf))
+	}
+	return nil
+}
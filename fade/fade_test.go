@@ -0,0 +1,94 @@
+package fade_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/fade"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+var format = wavetest.Format(1, 8000, 16)
+
+func TestCurve(t *testing.T) {
+	tt := []struct {
+		curve fade.Curve
+		out   string
+	}{
+		{fade.Linear, "[0.000 0.250 0.500 0.750 1.000]"},
+		{fade.EqualPower, "[0.000 0.383 0.707 0.924 1.000]"},
+		{fade.Logarithmic, "[0.000 0.006 0.032 0.178 1.000]"},
+	}
+	for _, tc := range tt {
+		var out []float64
+		for _, x := range []float64{0, 0.25, 0.5, 0.75, 1} {
+			out = append(out, tc.curve.Gain(x))
+		}
+		if got := fmt.Sprintf("%.3f", out); got != tc.out {
+			t.Fatalf("expected gains of curve %d to be %s, got %s", tc.curve, tc.out, got)
+		}
+	}
+}
+
+func TestFader(t *testing.T) {
+	f, err := fade.NewFader(2, 6, fade.Fade{Frames: 2}, fade.Fade{Frames: 4, Curve: fade.EqualPower})
+	if err != nil {
+		t.Fatalf("could not create fader: %v", err)
+	}
+	in := []float64{1, -1, 1, -1, 1, -1}
+	f.Process(in)
+	in2 := []float64{1, -1, 1, -1, 1, -1}
+	f.Process(in2)
+	exp := "[0.000 -0.000 0.500 -0.500 1.000 -1.000 0.924 -0.924 0.707 -0.707 0.383 -0.383]"
+	if got := fmt.Sprintf("%.3f", append(in, in2...)); got != exp {
+		t.Fatalf("expected samples to be\n%s, got\n%s", exp, got)
+	}
+	if _, err := fade.NewFader(1, -1, fade.Fade{}, fade.Fade{Frames: 1}); err == nil {
+		t.Fatalf("expected fade out without number of frames to fail")
+	}
+	if _, err := fade.NewFader(1, 3, fade.Fade{Frames: 2}, fade.Fade{Frames: 2}); err == nil {
+		t.Fatalf("expected fades longer than the file to fail")
+	}
+}
+
+func TestApply(t *testing.T) {
+	src := wavetest.Samples(t, format, []int{1000, 1000, 1000, 1000, 1000, 1000})
+	ws := wavetest.Write(t, format, func(wavw *wave.Writer) error {
+		return fade.Apply(context.Background(), wavw, src, fade.Fade{Frames: 2}, fade.Fade{Frames: 2})
+	})
+	if got, exp := fmt.Sprint(wavetest.ReadSamples(t, ws)), "[0 500 1000 1000 1000 500]"; got != exp {
+		t.Fatalf("expected samples to be %s, got %s", exp, got)
+	}
+}
+
+func TestCrossfade(t *testing.T) {
+	srcs := []*wave.Reader{
+		wavetest.Samples(t, format, []int{100, 100, 100, 100}),
+		wavetest.Samples(t, format, []int{200, 200, 200, 200, 200}),
+		wavetest.Samples(t, format, []int{400, 400, 400}),
+	}
+	ws := wavetest.Write(t, format, func(wavw *wave.Writer) error {
+		return fade.Crossfade(context.Background(), wavw, fade.Fade{Frames: 2}, srcs...)
+	})
+	exp := "[100 100 100 150 200 200 300 400]"
+	if got := fmt.Sprint(wavetest.ReadSamples(t, ws)); got != exp {
+		t.Fatalf("expected samples to be %s, got %s", exp, got)
+	}
+}
+
+func TestNoChannels(t *testing.T) {
+	wavw, err := wave.NewWriter(&writerseeker.WriterSeeker{}, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := fade.Apply(context.Background(), wavw, wavetest.NoChannels(t), fade.Fade{Frames: 2}, fade.Fade{}); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+	if err := fade.Crossfade(context.Background(), wavw, fade.Fade{}, wavetest.NoChannels(t)); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
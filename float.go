@@ -0,0 +1,102 @@
+package wave
+
+import (
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Floats reads samples into dst, scaled to the range [-1, 1). It returns the
+// number of samples read and io.EOF after the last one.
+func (wavr *Reader) Floats(dst []float64) (int, error) {
+	for i := range dst {
+		s, err := wavr.rawSample()
+		if err == io.EOF && i > 0 {
+			return i, nil
+		}
+		if err != nil {
+			return i, err
+		}
+		dst[i] = wavr.Format.float(s)
+	}
+	return len(dst), nil
+}
+
+// Floats writes samples in the range [-1, 1). Values outside of this range are
+// clipped.
+func (wavw *Writer) Floats(src []float64) error {
+	if _, err := wavw.Write(wavw.AppendFloats(nil, src)); err != nil {
+		return errors.Wrap(err, "could not write samples")
+	}
+	return nil
+}
+
+// AppendFloats encodes samples like Floats and appends them to p instead of
+// writing them. Together with Write this allows encoding and writing samples
+// on different goroutines.
+func (wavw *Writer) AppendFloats(p []byte, src []float64) []byte {
+	for _, v := range src {
+		p = wavw.fmt.appendSample(p, wavw.floatSample(v))
+	}
+	return p
+}
+
+// floatSample scales a value in the range [-1, 1) to a sample, dithered if
+// enabled, and clips it.
+func (wavw *Writer) floatSample(v float64) int {
+	if wavw.dither != nil {
+		v += (wavw.dither.Float64() - wavw.dither.Float64()) / wavw.fmt.fullScale()
+	}
+	if wavw.fmt.clips(v) {
+		wavw.clipped++
+	}
+	return wavw.fmt.int(v)
+}
+
+// DecodeFloats decodes the complete samples in p, as read by Reader.Read, into
+// dst scaled to the range [-1, 1). It returns the number of samples decoded.
+func (f Format) DecodeFloats(dst []float64, p []byte) int {
+	size := f.ContainerSize()
+	if !f.Decodable() {
+		return 0
+	}
+	n := 0
+	for ; n < len(dst) && (n+1)*size <= len(p); n++ {
+		dst[n] = f.float(f.decodeSample(p[n*size : (n+1)*size]))
+	}
+	return n
+}
+
+// fullScale returns the magnitude of the smallest sample.
+func (f Format) fullScale() float64 {
+	if f.BitsPerSample == 0 {
+		return 1
+	}
+	return float64(uint64(1) << (f.BitsPerSample - 1))
+}
+
+// float scales a sample to the range [-1, 1).
+func (f Format) float(s int) float64 {
+	if f.BitsPerSample == 8 {
+		s -= 128
+	}
+	return float64(s) / f.fullScale()
+}
+
+// int scales a value in the range [-1, 1) to a sample and clips it.
+func (f Format) int(v float64) int {
+	fs := f.fullScale()
+	v = math.Round(v * fs)
+	if v > fs-1 {
+		v = fs - 1
+	}
+	if v < -fs {
+		v = -fs
+	}
+	s := int(v)
+	if f.BitsPerSample == 8 {
+		s += 128
+	}
+	return s
+}
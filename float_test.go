@@ -0,0 +1,120 @@
+package wave_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+)
+
+func TestFloats(t *testing.T) {
+	tt := []struct {
+		bps     uint16
+		in, out []float64
+	}{
+		{8, []float64{0, 0.5, -0.5, -1, 2}, []float64{0, 0.5, -0.5, -1, 0.9921875}},
+		{16, []float64{0, 0.5, -0.5, -1, -2}, []float64{0, 0.5, -0.5, -1, -1}},
+		{24, []float64{0, 0.25, -0.25, 1}, []float64{0, 0.25, -0.25, 0.9999998807907104}},
+		{32, []float64{0, 0.125, -0.125}, []float64{0, 0.125, -0.125}},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprint(tc.bps), func(t *testing.T) {
+			format := wave.Format{
+				AudioFormat:   1,
+				NumChans:      1,
+				SampleRate:    8000,
+				ByteRate:      8000 * uint32(tc.bps) / 8,
+				BlockAlign:    tc.bps / 8,
+				BitsPerSample: tc.bps,
+			}
+			ws := &writerseeker.WriterSeeker{}
+			wavw, err := wave.NewWriter(ws, format)
+			if err != nil {
+				t.Fatalf("could not create wave writer: %v", err)
+			}
+			if err := wavw.Floats(tc.in); err != nil {
+				t.Fatalf("could not write floats: %v", err)
+			}
+			if err := wavw.Close(); err != nil {
+				t.Fatalf("could not close wave writer: %v", err)
+			}
+			wavr, err := wave.NewReader(ws.Reader())
+			if err != nil {
+				t.Fatalf("could not create wave reader: %v", err)
+			}
+			out := make([]float64, len(tc.out)+1)
+			n, err := wavr.Floats(out)
+			if err != nil {
+				t.Fatalf("could not read floats: %v", err)
+			}
+			if fmt.Sprint(out[:n]) != fmt.Sprint(tc.out) {
+				t.Fatalf("expected floats to be\n%v, got\n%v", tc.out, out[:n])
+			}
+			if _, err := wavr.Floats(out); err != io.EOF {
+				t.Fatalf("expected EOF, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReaderFloats(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	out := make([]float64, 4)
+	if _, err := wavr.Floats(out); err != nil {
+		t.Fatalf("could not read floats: %v", err)
+	}
+	if exp := "[0 0 0.1807861328125 -0.10064697265625]"; fmt.Sprint(out) != exp {
+		t.Fatalf("expected floats to be %s, got %v", exp, out)
+	}
+}
+
+func TestWriterDither(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      1,
+		SampleRate:    8000,
+		ByteRate:      16000,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format, wave.Dither())
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	// A quarter of a step is rounded to zero without dither.
+	in := make([]float64, 1000)
+	for i := range in {
+		in[i] = 0.25 / 32768
+	}
+	if err := wavw.Floats(in); err != nil {
+		t.Fatalf("could not write floats: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	wavr, err := wave.NewReader(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	sum := 0
+	for _, s := range samples {
+		if s < -1 || s > 1 {
+			t.Fatalf("expected dither of at most one step, got %d", s)
+		}
+		sum += s
+	}
+	if mean := float64(sum) / float64(len(samples)); mean < 0.15 || mean > 0.35 {
+		t.Fatalf("expected mean of dithered samples to be about 0.25, got %f", mean)
+	}
+}
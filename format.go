@@ -3,26 +3,229 @@ package wave
 import (
 	"encoding/binary"
 	"io"
+
+	"github.com/bake/wave/riff"
+	"github.com/pkg/errors"
 )
 
+// formatExtensible is the audio format of format chunks that are followed by
+// an extension with the actual format.
+const formatExtensible = 0xfffe
+
 // Format holds configuration about the WAVE.
 type Format struct {
 	AudioFormat   uint16 // 1 if PCM is used.
 	NumChans      uint16 // Number of channels (1 = mono, 2 = stereo, ...)
 	SampleRate    uint32 // Samples per second (44100, ...).
 	ByteRate      uint32 // Average bytes per second.
-	BlockAlign    uint16 // Bytes per sample.
-	BitsPerSample uint16 // Bits per sample.
+	BlockAlign    uint16 // Bytes per frame of all channels.
+	BitsPerSample uint16 // Significant bits per sample.
+	ChannelMask   uint32 // Speaker positions of the channels, 0 if unknown.
+}
+
+// formatFields is the binary layout of a format chunk.
+type formatFields struct {
+	AudioFormat   uint16
+	NumChans      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// formatExtension is the binary layout of the extension of a format chunk
+// using WAVE_FORMAT_EXTENSIBLE.
+type formatExtension struct {
+	Size        uint16
+	ValidBits   uint16
+	ChannelMask uint32
+	SubFormat   [16]byte // GUID starting with the audio format.
 }
 
-// decodeFormat decodes a chunk in a format chunk.
+// subFormatSuffix follows the audio format in the GUID of a sub format.
+var subFormatSuffix = [14]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}
+
+// Validate checks that the format describes PCM data this package can read and
+// that its fields are consistent with each other.
+func (f Format) Validate() error {
+	if f.AudioFormat != 1 {
+		return errors.Wrapf(ErrUnsupportedFormat, "audio format %d", f.AudioFormat)
+	}
+	if f.NumChans == 0 {
+		return errors.Wrap(ErrUnsupportedFormat, "no channels")
+	}
+	if f.SampleRate == 0 {
+		return errors.Wrap(ErrUnsupportedFormat, "sample rate of 0")
+	}
+	if f.BitsPerSample == 0 || f.BitsPerSample > 32 {
+		return errors.Wrapf(ErrUnsupportedFormat, "%d bits per sample", f.BitsPerSample)
+	}
+	size := (f.BitsPerSample + 7) / 8
+	if f.BlockAlign%f.NumChans != 0 || f.BlockAlign/f.NumChans < size || f.BlockAlign/f.NumChans > 4 {
+		return errors.Wrapf(ErrInconsistentBlockAlign, "expected %d channels * %d to 4 bytes, got %d",
+			f.NumChans, size, f.BlockAlign)
+	}
+	if f.ContainerSize() == 1 && f.BitsPerSample != 8 {
+		return errors.Wrapf(ErrUnsupportedFormat, "%d bits per sample in 8 bits", f.BitsPerSample)
+	}
+	if byteRate := uint64(f.SampleRate) * uint64(f.BlockAlign); uint64(f.ByteRate) != byteRate {
+		return errors.Wrapf(ErrInconsistentByteRate, "expected %d Hz * %d bytes = %d bytes, got %d",
+			f.SampleRate, f.BlockAlign, byteRate, f.ByteRate)
+	}
+	return nil
+}
+
+// CheckFrames checks that the format has channels and a block align, which
+// processing samples frame by frame requires. Unlike Validate, it accepts
+// formats that are merely inconsistent.
+func (f Format) CheckFrames() error {
+	if f.NumChans == 0 {
+		return errors.Wrap(ErrUnsupportedFormat, "no channels")
+	}
+	if f.BlockAlign == 0 {
+		return errors.Wrap(ErrUnsupportedFormat, "block align of 0")
+	}
+	return nil
+}
+
+// aligned reports if the block align holds a container of up to 4 bytes per
+// channel that is large enough for the samples.
+func (f Format) aligned() bool {
+	if f.NumChans == 0 || f.BlockAlign%f.NumChans != 0 {
+		return false
+	}
+	size := f.BlockAlign / f.NumChans
+	return size <= 4 && size*8 >= f.BitsPerSample
+}
+
+// ContainerSize returns the number of bytes a sample is stored in. It is
+// derived from the block align, so samples of less than BitsPerSample bits can
+// be padded, like 24 bit samples in 32 bit containers. Samples are stored in
+// the most significant bits of their container.
+func (f Format) ContainerSize() int {
+	if f.aligned() {
+		return int(f.BlockAlign / f.NumChans)
+	}
+	return int(f.BitsPerSample+7) / 8
+}
+
+// repair fixes fields that can be derived from others and returns what has
+// been changed. offset is the position of the format chunk.
+func (f *Format) repair(offset int64) []riff.Repair {
+	var repairs []riff.Repair
+	fix := func(declared, actual int64, reason string) {
+		repairs = append(repairs, riff.Repair{
+			Offset:   offset,
+			ID:       "fmt ",
+			Declared: declared,
+			Actual:   actual,
+			Reason:   reason,
+		})
+	}
+	// Block aligns of larger containers are kept.
+	size := (uint32(f.BitsPerSample) + 7) / 8
+	if blockAlign := uint32(f.NumChans) * size; blockAlign > 0 && blockAlign <= 0xffff && !f.aligned() {
+		fix(int64(f.BlockAlign), int64(blockAlign), "inconsistent block align")
+		f.BlockAlign = uint16(blockAlign)
+	}
+	if byteRate := uint64(f.SampleRate) * uint64(f.BlockAlign); byteRate > 0 && byteRate <= 0xffffffff && uint64(f.ByteRate) != byteRate {
+		fix(int64(f.ByteRate), int64(byteRate), "inconsistent byte rate")
+		f.ByteRate = uint32(byteRate)
+	}
+	return repairs
+}
+
+// decodeFormat decodes a chunk in a format chunk. The audio format of an
+// extensible format chunk is taken from its sub format.
 func decodeFormat(r io.Reader) (Format, error) {
-	var dst Format
-	err := binary.Read(r, binary.LittleEndian, &dst)
-	return dst, err
+	var f formatFields
+	if err := binary.Read(r, binary.LittleEndian, &f); err != nil {
+		return Format{}, err
+	}
+	dst := Format{
+		AudioFormat:   f.AudioFormat,
+		NumChans:      f.NumChans,
+		SampleRate:    f.SampleRate,
+		ByteRate:      f.ByteRate,
+		BlockAlign:    f.BlockAlign,
+		BitsPerSample: f.BitsPerSample,
+	}
+	if f.AudioFormat != formatExtensible {
+		return dst, nil
+	}
+	var ext formatExtension
+	if err := binary.Read(r, binary.LittleEndian, &ext); err != nil {
+		return Format{}, err
+	}
+	dst.AudioFormat = binary.LittleEndian.Uint16(ext.SubFormat[:2])
+	dst.ChannelMask = ext.ChannelMask
+	if ext.ValidBits > 0 && ext.ValidBits < f.BitsPerSample {
+		dst.BitsPerSample = ext.ValidBits
+	}
+	return dst, nil
 }
 
-// encode a format struct into an io.Writer.
+// encode a format struct into an io.Writer. Formats with a channel mask or
+// with containers larger than necessary are encoded as WAVE_FORMAT_EXTENSIBLE.
 func (f *Format) encode(w io.Writer) error {
-	return binary.Write(w, binary.LittleEndian, f)
+	fields := formatFields{
+		AudioFormat:   f.AudioFormat,
+		NumChans:      f.NumChans,
+		SampleRate:    f.SampleRate,
+		ByteRate:      f.ByteRate,
+		BlockAlign:    f.BlockAlign,
+		BitsPerSample: f.BitsPerSample,
+	}
+	size := uint16(f.ContainerSize())
+	if f.ChannelMask == 0 && size == (f.BitsPerSample+7)/8 {
+		return binary.Write(w, binary.LittleEndian, fields)
+	}
+	fields.AudioFormat = formatExtensible
+	fields.BitsPerSample = size * 8
+	ext := formatExtension{
+		Size:        22,
+		ValidBits:   f.BitsPerSample,
+		ChannelMask: f.ChannelMask,
+	}
+	binary.LittleEndian.PutUint16(ext.SubFormat[:2], f.AudioFormat)
+	copy(ext.SubFormat[2:], subFormatSuffix[:])
+	if err := binary.Write(w, binary.LittleEndian, fields); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, ext)
+}
+
+// decodeSample decodes a little endian sample in a container of 1 to 4 bytes.
+// 8 bit samples are unsigned.
+func (f Format) decodeSample(p []byte) int {
+	if len(p) == 1 {
+		return int(p[0])
+	}
+	// Shift the sample into the upper bytes to keep its sign.
+	var v int32
+	for i, b := range p {
+		v |= int32(b) << uint(8*(4-len(p)+i))
+	}
+	return int(v >> uint(32-f.BitsPerSample))
+}
+
+// appendSample encodes a sample into a container of 1 to 4 bytes and appends
+// it to p.
+func (f Format) appendSample(p []byte, s int) []byte {
+	size := f.ContainerSize()
+	if size == 1 {
+		return append(p, byte(s))
+	}
+	v := uint32(s) << uint(size*8-int(f.BitsPerSample))
+	for i := 0; i < size; i++ {
+		p = append(p, byte(v>>uint(8*i)))
+	}
+	return p
+}
+
+// Decodable reports if samples of the format can be decoded into integers and
+// floats.
+func (f Format) Decodable() bool {
+	size := f.ContainerSize()
+	return f.BitsPerSample > 0 && size <= 4 && (size > 1 || f.BitsPerSample == 8)
 }
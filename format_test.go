@@ -0,0 +1,267 @@
+package wave_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+func TestFormatValidate(t *testing.T) {
+	valid := wave.Format{
+		AudioFormat:   1,
+		NumChans:      2,
+		SampleRate:    44100,
+		ByteRate:      176400,
+		BlockAlign:    4,
+		BitsPerSample: 16,
+	}
+	tt := []struct {
+		name   string
+		modify func(f *wave.Format)
+		err    error
+	}{
+		{"valid", func(f *wave.Format) {}, nil},
+		{"float", func(f *wave.Format) { f.AudioFormat = 3 }, wave.ErrUnsupportedFormat},
+		{"no channels", func(f *wave.Format) { f.NumChans = 0 }, wave.ErrUnsupportedFormat},
+		{"no sample rate", func(f *wave.Format) { f.SampleRate = 0 }, wave.ErrUnsupportedFormat},
+		{"0 bps", func(f *wave.Format) { f.BitsPerSample = 0 }, wave.ErrUnsupportedFormat},
+		{"33 bps", func(f *wave.Format) { f.BitsPerSample = 33 }, wave.ErrUnsupportedFormat},
+		{"12 in 16 bits", func(f *wave.Format) { f.BitsPerSample = 12 }, nil},
+		{"24 in 32 bits", func(f *wave.Format) { f.BitsPerSample, f.BlockAlign, f.ByteRate = 24, 8, 352800 }, nil},
+		{"24 in 16 bits", func(f *wave.Format) { f.BitsPerSample = 24 }, wave.ErrInconsistentBlockAlign},
+		{"4 in 8 bits", func(f *wave.Format) { f.BitsPerSample, f.BlockAlign, f.ByteRate = 4, 2, 88200 }, wave.ErrUnsupportedFormat},
+		{"block align", func(f *wave.Format) { f.BlockAlign = 2 }, wave.ErrInconsistentBlockAlign},
+		{"odd block align", func(f *wave.Format) { f.BlockAlign, f.ByteRate = 5, 220500 }, wave.ErrInconsistentBlockAlign},
+		{"byte rate", func(f *wave.Format) { f.ByteRate = 88200 }, wave.ErrInconsistentByteRate},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f := valid
+			tc.modify(&f)
+			err := f.Validate()
+			if tc.err == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error to be %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestFormatCheckFrames(t *testing.T) {
+	format := wave.Format{AudioFormat: 3, NumChans: 2, BlockAlign: 5, BitsPerSample: 16}
+	if err := format.CheckFrames(); err != nil {
+		t.Fatalf("expected inconsistent format to have frames, got %v", err)
+	}
+	for _, f := range []wave.Format{{NumChans: 0, BlockAlign: 4}, {NumChans: 2, BlockAlign: 0}} {
+		if err := f.CheckFrames(); !errors.Is(err, wave.ErrUnsupportedFormat) {
+			t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+		}
+	}
+}
+
+func TestFormatExtensible(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      1,
+		SampleRate:    8000,
+		ByteRate:      16000,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+		ChannelMask:   wave.SpeakerFrontLeft,
+	}
+	out := []byte{
+		// R,    I,    F,    F,                     64,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x40, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+
+		// f,    m,    t,    ␣,                     40,     0xfffe,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x28, 0x00, 0x00, 0x00, 0xfe, 0xff, 0x01, 0x00,
+		//                8000,                  16000,          2,         16,
+		0x40, 0x1f, 0x00, 0x00, 0x80, 0x3e, 0x00, 0x00, 0x02, 0x00, 0x10, 0x00,
+		//      22,         16,                      1,          1,
+		0x16, 0x00, 0x10, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71,
+
+		// d,    a,    t,    a,                      4,          1,         -1,
+		0x64, 0x61, 0x74, 0x61, 0x04, 0x00, 0x00, 0x00, 0x01, 0x00, 0xff, 0xff,
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples([]int{1, -1}); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	body, _ := ioutil.ReadAll(ws.Reader())
+	if fmt.Sprintf("% x", body) != fmt.Sprintf("% x", out) {
+		t.Fatalf("expected body to be\n% x, got\n% x\n", out, body)
+	}
+	wavr, err := wave.NewReader(bytes.NewReader(out), wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if wavr.Format != format {
+		t.Fatalf("expected format to be %v, got %v", format, wavr.Format)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if fmt.Sprint(samples) != "[1 -1]" {
+		t.Fatalf("expected samples to be [1 -1], got %v", samples)
+	}
+}
+
+func TestFormatContainer(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      2,
+		SampleRate:    48000,
+		ByteRate:      384000,
+		BlockAlign:    8,
+		BitsPerSample: 24,
+	}
+	out := []byte{
+		// R,    I,    F,    F,                     76,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x4c, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+
+		// f,    m,    t,    ␣,                     40,     0xfffe,          2,
+		0x66, 0x6d, 0x74, 0x20, 0x28, 0x00, 0x00, 0x00, 0xfe, 0xff, 0x02, 0x00,
+		//               48000,                 384000,          8,         32,
+		0x80, 0xbb, 0x00, 0x00, 0x00, 0xdc, 0x05, 0x00, 0x08, 0x00, 0x20, 0x00,
+		//      22,         24,                      0,          1,
+		0x16, 0x00, 0x18, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71,
+
+		// d,    a,    t,    a,                     16,                      1,
+		0x64, 0x61, 0x74, 0x61, 0x10, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+		//                  -1,                8388607,               -8388608,
+		0x00, 0xff, 0xff, 0xff, 0x00, 0xff, 0xff, 0x7f, 0x00, 0x00, 0x00, 0x80,
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples([]int{1, -1, 8388607, -8388608}); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	body, _ := ioutil.ReadAll(ws.Reader())
+	if fmt.Sprintf("% x", body) != fmt.Sprintf("% x", out) {
+		t.Fatalf("expected body to be\n% x, got\n% x\n", out, body)
+	}
+	wavr, err := wave.NewReader(bytes.NewReader(out), wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if wavr.Format != format {
+		t.Fatalf("expected format to be %v, got %v", format, wavr.Format)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if exp := "[1 -1 8388607 -8388608]"; fmt.Sprint(samples) != exp {
+		t.Fatalf("expected samples to be %s, got %v", exp, samples)
+	}
+}
+
+func TestFormatValidBits(t *testing.T) {
+	tt := []struct {
+		bps, blockAlign uint16
+		samples         []int
+		data            string
+		floats          string
+	}{
+		{12, 2, []int{1, -1, 2047, -2048}, "10 00 f0 ff f0 7f 00 80", "[0.00048828125 -0.00048828125 0.99951171875 -1]"},
+		{20, 3, []int{1, -1, 524287}, "10 00 00 f0 ff ff f0 ff 7f", "[1.9073486328125e-06 -1.9073486328125e-06 0.9999980926513672]"},
+		{20, 4, []int{1, -524288}, "00 10 00 00 00 00 00 80", "[1.9073486328125e-06 -1]"},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("%d in %d", tc.bps, tc.blockAlign*8), func(t *testing.T) {
+			format := wave.Format{
+				AudioFormat:   1,
+				NumChans:      1,
+				SampleRate:    8000,
+				ByteRate:      8000 * uint32(tc.blockAlign),
+				BlockAlign:    tc.blockAlign,
+				BitsPerSample: tc.bps,
+			}
+			ws := &writerseeker.WriterSeeker{}
+			wavw, err := wave.NewWriter(ws, format)
+			if err != nil {
+				t.Fatalf("could not create wave writer: %v", err)
+			}
+			if err := wavw.Samples(tc.samples); err != nil {
+				t.Fatalf("could not write samples: %v", err)
+			}
+			if err := wavw.Close(); err != nil {
+				t.Fatalf("could not close wave writer: %v", err)
+			}
+			wavr, err := wave.NewReader(ws.Reader(), wave.Strict())
+			if err != nil {
+				t.Fatalf("could not create wave reader: %v", err)
+			}
+			if wavr.Format != format {
+				t.Fatalf("expected format to be %v, got %v", format, wavr.Format)
+			}
+			data, err := ioutil.ReadAll(wavr)
+			if err != nil {
+				t.Fatalf("could not read data: %v", err)
+			}
+			if got := fmt.Sprintf("% x", data); got != tc.data {
+				t.Fatalf("expected data to be %s, got %s", tc.data, got)
+			}
+			floats := make([]float64, len(tc.samples))
+			n := format.DecodeFloats(floats, data)
+			if got := fmt.Sprint(floats[:n]); got != tc.floats {
+				t.Fatalf("expected floats to be %s, got %s", tc.floats, got)
+			}
+			if err := wavr.Rewind(); err != nil {
+				t.Fatalf("could not rewind: %v", err)
+			}
+			samples, err := wavr.Samples()
+			if err != nil {
+				t.Fatalf("could not read samples: %v", err)
+			}
+			if fmt.Sprint(samples) != fmt.Sprint(tc.samples) {
+				t.Fatalf("expected samples to be %v, got %v", tc.samples, samples)
+			}
+		})
+	}
+}
+
+func TestFormatSpeakers(t *testing.T) {
+	tt := []struct {
+		format wave.Format
+		out    []uint32
+	}{
+		{wave.Format{NumChans: 1}, []uint32{wave.SpeakerFrontCenter}},
+		{wave.Format{NumChans: 6}, []uint32{
+			wave.SpeakerFrontLeft, wave.SpeakerFrontRight, wave.SpeakerFrontCenter,
+			wave.SpeakerLowFrequency, wave.SpeakerBackLeft, wave.SpeakerBackRight,
+		}},
+		{wave.Format{NumChans: 3, ChannelMask: wave.SpeakerSideLeft | wave.SpeakerFrontRight}, []uint32{
+			wave.SpeakerFrontRight, wave.SpeakerSideLeft, 0,
+		}},
+		{wave.Format{NumChans: 7}, []uint32{0, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tc := range tt {
+		if got := tc.format.Speakers(); fmt.Sprint(got) != fmt.Sprint(tc.out) {
+			t.Fatalf("expected speakers of %d channels to be %v, got %v", tc.format.NumChans, tc.out, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package wave_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/bake/wave"
+)
+
+// maxFuzzSamples limits the samples read from a fuzzed file, since silence
+// chunks may expand to billions of samples.
+const maxFuzzSamples = 1 << 16
+
+func FuzzNewReader(f *testing.F) {
+	f.Add(exampleInt16Wave())
+	f.Add(exampleWaveList())
+	f.Add(exampleInt16Wave()[:60])
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, opts := range [][]wave.ReaderOption{nil, {wave.Strict()}, {wave.Lenient()}} {
+			for _, r := range []io.Reader{bytes.NewReader(data), iotest.HalfReader(bytes.NewBuffer(data))} {
+				wavr, err := wave.NewReader(r, opts...)
+				if err != nil {
+					continue
+				}
+				wavr.Repairs()
+				if _, ok := r.(io.Seeker); ok {
+					wavr.Segments()
+					wavr.NumFrames()
+					wavr.Cues()
+				}
+				wavr.Bext()
+				for i := 0; i < maxFuzzSamples; i++ {
+					if _, err := wavr.Sample(); err != nil {
+						break
+					}
+				}
+				if wavr.Rewind() != nil {
+					continue
+				}
+				buf := make([]float64, 64)
+				for i := 0; i < maxFuzzSamples; i += len(buf) {
+					if _, err := wavr.Floats(buf); err != nil {
+						break
+					}
+				}
+			}
+		}
+	})
+}
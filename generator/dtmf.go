@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"math"
+
+	"github.com/bake/wave"
+	"github.com/pkg/errors"
+)
+
+// dtmfFreqs are the row and column frequencies of the keys of a telephone.
+var dtmfFreqs = map[rune][2]float64{
+	'1': {697, 1209}, '2': {697, 1336}, '3': {697, 1477}, 'A': {697, 1633},
+	'4': {770, 1209}, '5': {770, 1336}, '6': {770, 1477}, 'B': {770, 1633},
+	'7': {852, 1209}, '8': {852, 1336}, '9': {852, 1477}, 'C': {852, 1633},
+	'*': {941, 1209}, '0': {941, 1336}, '#': {941, 1477}, 'D': {941, 1633},
+}
+
+// DTMF generates the dual tones of the telephone keys in digits, each lasting
+// tone frames and followed by pause frames of silence. Both tones have a
+// level of level dBFS.
+func DTMF(format wave.Format, digits string, level float64, tone, pause int64) (*Generator, error) {
+	if tone <= 0 || pause < 0 {
+		return nil, errors.Errorf("invalid DTMF durations of %d and %d frames", tone, pause)
+	}
+	var freqs [][2]float64
+	for _, d := range digits {
+		f, ok := dtmfFreqs[d]
+		if !ok {
+			return nil, errors.Errorf("invalid DTMF digit %q", d)
+		}
+		freqs = append(freqs, f)
+	}
+	a := amplitude(level)
+	period := tone + pause
+	frames := int64(len(freqs)) * period
+	return New(format, frames, func(i int64) float64 {
+		j := i % period
+		if j >= tone {
+			return 0
+		}
+		f := freqs[i/period]
+		return a * (math.Sin(2*math.Pi*phase(format, f[0], j)) + math.Sin(2*math.Pi*phase(format, f[1], j)))
+	}), nil
+}
@@ -0,0 +1,178 @@
+// Package generator synthesizes test signals as sources of samples.
+package generator
+
+import (
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/bake/wave"
+)
+
+// Generator is a wave.Source of a synthesized signal. Every channel carries
+// the same signal.
+type Generator struct {
+	format wave.Format
+	frames int64 // Number of frames, negative for an endless signal.
+	pos    int64
+	fn     func(i int64) float64
+}
+
+var _ wave.Source = (*Generator)(nil)
+
+// New creates a generator of frames frames, whose i-th frame is fn(i). If
+// frames is negative, the signal never ends.
+func New(format wave.Format, frames int64, fn func(i int64) float64) *Generator {
+	return &Generator{format: format, frames: frames, fn: fn}
+}
+
+// FrameFormat returns the format of the frames.
+func (g *Generator) FrameFormat() wave.Format { return g.format }
+
+// Frames returns the number of frames or -1 if the signal never ends.
+func (g *Generator) Frames() int64 {
+	if g.frames < 0 {
+		return -1
+	}
+	return g.frames
+}
+
+// Floats writes complete frames into dst. It returns the number of samples
+// written and io.EOF after the last frame.
+func (g *Generator) Floats(dst []float64) (int, error) {
+	chans := int(g.format.NumChans)
+	if chans == 0 {
+		return 0, io.EOF
+	}
+	n := 0
+	for ; n+chans <= len(dst); n += chans {
+		if g.frames >= 0 && g.pos >= g.frames {
+			break
+		}
+		v := g.fn(g.pos)
+		for c := 0; c < chans; c++ {
+			dst[n+c] = v
+		}
+		g.pos++
+	}
+	if n == 0 && g.frames >= 0 && g.pos >= g.frames {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// amplitude converts a level in dBFS into an amplitude.
+func amplitude(level float64) float64 {
+	return math.Pow(10, level/20)
+}
+
+// phase returns the position of frame i within a period of freq Hz, between 0
+// and 1.
+func phase(format wave.Format, freq float64, i int64) float64 {
+	_, frac := math.Modf(freq * float64(i) / float64(format.SampleRate))
+	return frac
+}
+
+// Sine generates a sine wave of freq Hz at level dBFS.
+func Sine(format wave.Format, freq, level float64, frames int64) *Generator {
+	a := amplitude(level)
+	return New(format, frames, func(i int64) float64 {
+		return a * math.Sin(2*math.Pi*phase(format, freq, i))
+	})
+}
+
+// Square generates a square wave of freq Hz at level dBFS.
+func Square(format wave.Format, freq, level float64, frames int64) *Generator {
+	a := amplitude(level)
+	return New(format, frames, func(i int64) float64 {
+		if phase(format, freq, i) < 0.5 {
+			return a
+		}
+		return -a
+	})
+}
+
+// Sawtooth generates a rising sawtooth wave of freq Hz at level dBFS.
+func Sawtooth(format wave.Format, freq, level float64, frames int64) *Generator {
+	a := amplitude(level)
+	return New(format, frames, func(i int64) float64 {
+		return a * (2*phase(format, freq, i) - 1)
+	})
+}
+
+// WhiteNoise generates uniformly distributed noise with peaks at level dBFS.
+// The same seed produces the same noise.
+func WhiteNoise(format wave.Format, level float64, seed int64, frames int64) *Generator {
+	a := amplitude(level)
+	rnd := rand.New(rand.NewSource(seed))
+	return New(format, frames, func(int64) float64 {
+		return a * (2*rnd.Float64() - 1)
+	})
+}
+
+// PinkNoise generates noise whose power falls by 3 dB per octave, with peaks
+// at about level dBFS. The same seed produces the same noise.
+func PinkNoise(format wave.Format, level float64, seed int64, frames int64) *Generator {
+	a := amplitude(level)
+	rnd := rand.New(rand.NewSource(seed))
+	// Paul Kellet's refined filter of white noise.
+	var b [7]float64
+	return New(format, frames, func(int64) float64 {
+		w := 2*rnd.Float64() - 1
+		b[0] = 0.99886*b[0] + w*0.0555179
+		b[1] = 0.99332*b[1] + w*0.0750759
+		b[2] = 0.96900*b[2] + w*0.1538520
+		b[3] = 0.86650*b[3] + w*0.3104856
+		b[4] = 0.55000*b[4] + w*0.5329522
+		b[5] = -0.7616*b[5] - w*0.0168980
+		v := b[0] + b[1] + b[2] + b[3] + b[4] + b[5] + b[6] + w*0.5362
+		b[6] = w * 0.115926
+		// The sum peaks at about 5.
+		return clip(a * v / 5)
+	})
+}
+
+// Sweep generates a sine wave at level dBFS whose frequency rises or falls
+// exponentially from from to to Hz over all frames. If either frequency is 0
+// or below, it changes linearly instead.
+func Sweep(format wave.Format, from, to, level float64, frames int64) *Generator {
+	a := amplitude(level)
+	rate := float64(format.SampleRate)
+	duration := float64(frames) / rate
+	linear := from <= 0 || to <= 0
+	k := 0.0
+	if !linear {
+		k = math.Log(to / from)
+	}
+	return New(format, frames, func(i int64) float64 {
+		t := float64(i) / rate
+		switch {
+		case linear && duration > 0:
+			return a * math.Sin(2*math.Pi*(from*t+(to-from)*t*t/(2*duration)))
+		case k == 0 || duration == 0:
+			return a * math.Sin(2*math.Pi*from*t)
+		}
+		return a * math.Sin(2*math.Pi*from*duration/k*(math.Exp(t/duration*k)-1))
+	})
+}
+
+// Impulse generates a single sample at level dBFS followed by silence.
+func Impulse(format wave.Format, level float64, frames int64) *Generator {
+	a := amplitude(level)
+	return New(format, frames, func(i int64) float64 {
+		if i == 0 {
+			return a
+		}
+		return 0
+	})
+}
+
+// Silence generates silence.
+func Silence(format wave.Format, frames int64) *Generator {
+	return New(format, frames, func(int64) float64 { return 0 })
+}
+
+// clip limits v to the range [-1, 1].
+func clip(v float64) float64 {
+	return math.Max(-1, math.Min(1, v))
+}
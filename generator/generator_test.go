@@ -0,0 +1,155 @@
+package generator_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/generator"
+	"github.com/bake/wave/pipeline"
+	"github.com/orcaman/writerseeker"
+)
+
+func format(chans int) wave.Format {
+	return wave.Format{
+		AudioFormat:   1,
+		NumChans:      uint16(chans),
+		SampleRate:    8000,
+		ByteRate:      uint32(8000 * chans * 2),
+		BlockAlign:    uint16(chans * 2),
+		BitsPerSample: 16,
+	}
+}
+
+// readAll returns all samples of a source.
+func readAll(t *testing.T, src wave.Source) []float64 {
+	var samples []float64
+	buf := make([]float64, 5)
+	for {
+		n, err := src.Floats(buf)
+		samples = append(samples, buf[:n]...)
+		if err == io.EOF {
+			return samples
+		}
+		if err != nil {
+			t.Fatalf("could not read samples: %v", err)
+		}
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	tt := []struct {
+		name string
+		g    *generator.Generator
+		exp  string
+	}{
+		{"sine", generator.Sine(format(1), 1000, 0, 9), "[0.00 0.71 1.00 0.71 0.00 -0.71 -1.00 -0.71 0.00]"},
+		{"square", generator.Square(format(1), 2000, -6.0206, 4), "[0.50 0.50 -0.50 -0.50]"},
+		{"sawtooth", generator.Sawtooth(format(1), 2000, 0, 5), "[-1.00 -0.50 0.00 0.50 -1.00]"},
+		{"impulse", generator.Impulse(format(2), 0, 3), "[1.00 1.00 0.00 0.00 0.00 0.00]"},
+		{"silence", generator.Silence(format(2), 2), "[0.00 0.00 0.00 0.00]"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fmt.Sprintf("%.2f", readAll(t, tc.g)); got != tc.exp {
+				t.Fatalf("expected samples to be %s, got %s", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestNoise(t *testing.T) {
+	for _, tc := range []struct {
+		fn   func(seed int64) *generator.Generator
+		peak float64
+	}{
+		{func(seed int64) *generator.Generator { return generator.WhiteNoise(format(1), -6, seed, 8000) }, 0.51},
+		// Pink noise only peaks at about the level.
+		{func(seed int64) *generator.Generator { return generator.PinkNoise(format(1), -6, seed, 8000) }, 0.75},
+	} {
+		fn := tc.fn
+		a, b, c := readAll(t, fn(1)), readAll(t, fn(1)), readAll(t, fn(2))
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			t.Fatalf("expected noise of the same seed to be equal")
+		}
+		if fmt.Sprint(a) == fmt.Sprint(c) {
+			t.Fatalf("expected noise of different seeds to differ")
+		}
+		for _, v := range a {
+			if math.Abs(v) > tc.peak {
+				t.Fatalf("expected noise to peak below %.2f, got %f", tc.peak, v)
+			}
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	// Count zero crossings of the first and the last tenth of a second.
+	crossings := func(samples []float64) int {
+		var n int
+		for i := 1; i < len(samples); i++ {
+			if (samples[i-1] < 0) != (samples[i] < 0) {
+				n++
+			}
+		}
+		return n
+	}
+	for _, from := range []float64{100, 0} {
+		samples := readAll(t, generator.Sweep(format(1), from, 1000, 0, 8000))
+		for i, v := range samples {
+			if math.IsNaN(v) {
+				t.Fatalf("expected sample %d of a sweep from %.0f Hz to be a number", i, from)
+			}
+		}
+		if first, last := crossings(samples[:800]), crossings(samples[7200:]); first >= 30 || last <= 150 {
+			t.Fatalf("expected the frequency to rise from %.0f Hz, got %d and %d zero crossings", from, first, last)
+		}
+	}
+}
+
+func TestDTMF(t *testing.T) {
+	g, err := generator.DTMF(format(1), "1#", -6, 400, 200)
+	if err != nil {
+		t.Fatalf("could not create DTMF generator: %v", err)
+	}
+	samples := readAll(t, g)
+	if len(samples) != 1200 || g.Frames() != 1200 {
+		t.Fatalf("expected 1200 frames, got %d", len(samples))
+	}
+	for _, v := range samples[400:600] {
+		if v != 0 {
+			t.Fatalf("expected a pause between digits, got %f", v)
+		}
+	}
+	if _, err := generator.DTMF(format(1), "12x", -6, 400, 200); err == nil {
+		t.Fatalf("expected x to be an invalid digit")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format(2))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if _, err := pipeline.Run(context.Background(), wavw, generator.Sine(format(2), 2000, -6.0206, 4)); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	wavr, err := wave.NewReader(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if got, exp := fmt.Sprint(samples), "[0 0 16384 16384 0 0 -16384 -16384]"; got != exp {
+		t.Fatalf("expected samples to be %s, got %s", exp, got)
+	}
+}
@@ -0,0 +1,94 @@
+package wave
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// SampleType is a type samples can be converted from and to. Integer types
+// are signed and use their full range, so an 8 bit sample of 255 is read as an
+// int8 of 127 and as an int16 of 32512. Floating point types use the range
+// [-1, 1).
+type SampleType interface {
+	int8 | int16 | int32 | float32 | float64
+}
+
+// sampleBits returns the number of bits of an integer sample type or 0 for
+// floating point types.
+func sampleBits[T SampleType]() uint16 {
+	var v T
+	switch any(v).(type) {
+	case int8:
+		return 8
+	case int16:
+		return 16
+	case int32:
+		return 32
+	}
+	return 0
+}
+
+// ReadSamples reads samples into dst, converted to type T. It returns the
+// number of samples read and io.EOF after the last one.
+func ReadSamples[T SampleType](wavr *Reader, dst []T) (int, error) {
+	bits := sampleBits[T]()
+	for i := range dst {
+		s, err := wavr.rawSample()
+		if err == io.EOF && i > 0 {
+			return i, nil
+		}
+		if err != nil {
+			return i, err
+		}
+		if bits == 0 {
+			dst[i] = T(wavr.Format.float(s))
+			continue
+		}
+		if wavr.Format.BitsPerSample == 8 {
+			s -= 128
+		}
+		dst[i] = T(shiftSample(s, wavr.Format.BitsPerSample, bits))
+	}
+	return len(dst), nil
+}
+
+// ReadAllSamples reads the whole file and returns all samples converted to
+// type T.
+func ReadAllSamples[T SampleType](wavr *Reader) ([]T, error) {
+	var samples []T
+	buf := make([]T, 4096)
+	for {
+		n, err := ReadSamples(wavr, buf)
+		samples = append(samples, buf[:n]...)
+		if err == io.EOF {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WriteSamples converts samples of type T to the format of the writer and
+// writes them. Floating point samples outside of the range [-1, 1) are clipped.
+func WriteSamples[T SampleType](wavw *Writer, src []T) error {
+	bits := sampleBits[T]()
+	p := make([]byte, 0, len(src)*wavw.fmt.ContainerSize())
+	for _, v := range src {
+		var s int
+		if bits == 0 {
+			s = wavw.floatSample(float64(v))
+		} else {
+			s = shiftSample(int(v), bits, wavw.fmt.BitsPerSample)
+			if wavw.fmt.BitsPerSample == 8 {
+				s += 128
+			}
+		}
+		p = wavw.fmt.appendSample(p, s)
+	}
+	if _, err := wavw.Write(p); err != nil {
+		return errors.Wrap(err, "could not write samples")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package wave_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+)
+
+// first reads all samples as type T and returns the first six.
+func first[T wave.SampleType](wavr *wave.Reader) (string, error) {
+	samples, err := wave.ReadAllSamples[T](wavr)
+	if err != nil || len(samples) < 6 {
+		return fmt.Sprint(samples), err
+	}
+	return fmt.Sprint(samples[:6]), nil
+}
+
+func TestReadSamples(t *testing.T) {
+	read := func(t *testing.T) *wave.Reader {
+		wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+		if err != nil {
+			t.Fatalf("could not create wave reader: %v", err)
+		}
+		return wavr
+	}
+	tt := []struct {
+		name string
+		read func(wavr *wave.Reader) (string, error)
+		exp  string
+	}{
+		{"int8", first[int8], "[0 0 23 -13 19 20]"},
+		{"int16", first[int16], "[0 0 5924 -3298 4924 5180]"},
+		{"int32", first[int32], "[0 0 388235264 -216137728 322699264 339476480]"},
+		{"float32", first[float32], "[0 0 0.18078613 -0.10064697 0.15026855 0.15808105]"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.read(read(t))
+			if err != nil {
+				t.Fatalf("could not read samples: %v", err)
+			}
+			if got != tc.exp {
+				t.Fatalf("expected samples to start with %s, got %s", tc.exp, got)
+			}
+		})
+	}
+
+	wavr := read(t)
+	dst := make([]int16, 20)
+	if n, err := wave.ReadSamples(wavr, dst); n != 20 || err != nil {
+		t.Fatalf("expected 20 samples, got %d: %v", n, err)
+	}
+	if n, err := wave.ReadSamples(wavr, dst); n != 2 || err != nil {
+		t.Fatalf("expected 2 samples, got %d: %v", n, err)
+	}
+	if _, err := wave.ReadSamples(wavr, dst); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestWriteSamples(t *testing.T) {
+	tt := []struct {
+		bps   uint16
+		write func(wavw *wave.Writer) error
+		exp   string
+	}{
+		{8, func(wavw *wave.Writer) error { return wave.WriteSamples(wavw, []int16{0, 5924, -32768, 32767}) }, "[128 151 0 255]"},
+		{16, func(wavw *wave.Writer) error { return wave.WriteSamples(wavw, []int8{0, 1, -128, 127}) }, "[0 256 -32768 32512]"},
+		{24, func(wavw *wave.Writer) error { return wave.WriteSamples(wavw, []int32{0, 256, -1 << 31, 1<<31 - 1}) }, "[0 1 -8388608 8388607]"},
+		{16, func(wavw *wave.Writer) error { return wave.WriteSamples(wavw, []float32{0, 0.5, -2, 2}) }, "[0 16384 -32768 32767]"},
+		{8, func(wavw *wave.Writer) error { return wave.WriteSamples(wavw, []float64{0, -0.5, 1}) }, "[128 64 255]"},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprint(tc.bps), func(t *testing.T) {
+			format := wave.Format{
+				AudioFormat:   1,
+				NumChans:      1,
+				SampleRate:    8000,
+				ByteRate:      8000 * uint32(tc.bps) / 8,
+				BlockAlign:    tc.bps / 8,
+				BitsPerSample: tc.bps,
+			}
+			ws := &writerseeker.WriterSeeker{}
+			wavw, err := wave.NewWriter(ws, format)
+			if err != nil {
+				t.Fatalf("could not create wave writer: %v", err)
+			}
+			if err := tc.write(wavw); err != nil {
+				t.Fatalf("could not write samples: %v", err)
+			}
+			if err := wavw.Close(); err != nil {
+				t.Fatalf("could not close wave writer: %v", err)
+			}
+			wavr, err := wave.NewReader(ws.Reader())
+			if err != nil {
+				t.Fatalf("could not create wave reader: %v", err)
+			}
+			samples, err := wavr.Samples()
+			if err != nil {
+				t.Fatalf("could not read samples: %v", err)
+			}
+			if fmt.Sprint(samples) != tc.exp {
+				t.Fatalf("expected samples to be %s, got %v", tc.exp, samples)
+			}
+		})
+	}
+}
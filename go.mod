@@ -1,8 +1,8 @@
 module github.com/bake/wave
 
-go 1.12
+go 1.18
 
 require (
 	github.com/orcaman/writerseeker v0.0.0-20180723184025-774071c66cec
-	github.com/pkg/errors v0.8.1
+	github.com/pkg/errors v0.9.1
 )
@@ -0,0 +1,99 @@
+// Package wavetest provides helpers for tests of packages that read and write
+// WAVE files.
+package wavetest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+)
+
+// Format returns a PCM format of chans channels.
+func Format(chans, rate, bits int) wave.Format {
+	align := chans * ((bits + 7) / 8)
+	return wave.Format{
+		AudioFormat:   1,
+		NumChans:      uint16(chans),
+		SampleRate:    uint32(rate),
+		ByteRate:      uint32(rate * align),
+		BlockAlign:    uint16(align),
+		BitsPerSample: uint16(bits),
+	}
+}
+
+// Write creates a file in format, writes into it using fn and closes it.
+func Write(t testing.TB, format wave.Format, fn func(wavw *wave.Writer) error, opts ...wave.WriterOption) *writerseeker.WriterSeeker {
+	t.Helper()
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format, opts...)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := fn(wavw); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	return ws
+}
+
+// Read returns a reader of a file created by Write.
+func Read(t testing.TB, ws *writerseeker.WriterSeeker, opts ...wave.ReaderOption) *wave.Reader {
+	t.Helper()
+	wavr, err := wave.NewReader(ws.Reader(), opts...)
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	return wavr
+}
+
+// ReadSamples returns all samples of a file created by Write. The file is read
+// in strict mode.
+func ReadSamples(t testing.TB, ws *writerseeker.WriterSeeker) []int {
+	t.Helper()
+	samples, err := Read(t, ws, wave.Strict()).Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	return samples
+}
+
+// Samples writes samples into a new file and returns a reader of it.
+func Samples(t testing.TB, format wave.Format, samples []int, opts ...wave.WriterOption) *wave.Reader {
+	t.Helper()
+	return Read(t, Write(t, format, func(wavw *wave.Writer) error {
+		return wavw.Samples(samples)
+	}, opts...))
+}
+
+// Floats writes samples in the range [-1, 1) into a new file and returns a
+// reader of it.
+func Floats(t testing.TB, format wave.Format, samples []float64, opts ...wave.WriterOption) *wave.Reader {
+	t.Helper()
+	return Read(t, Write(t, format, func(wavw *wave.Writer) error {
+		return wavw.Floats(samples)
+	}, opts...))
+}
+
+// NoChannels returns a reader of a file whose format has neither channels nor a
+// block align.
+func NoChannels(t testing.TB) *wave.Reader {
+	t.Helper()
+	wavr, err := wave.NewReader(bytes.NewReader([]byte{
+		// R,    I,    F,    F,                     40,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x28, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+		// f,    m,    t,    ␣,                     16,          1,          0,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		//                8000,                      0,          0,         16,
+		0x40, 0x1f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00,
+		// d,    a,    t,    a,                      4,
+		0x64, 0x61, 0x74, 0x61, 0x04, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00,
+	}))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	return wavr
+}
@@ -0,0 +1,75 @@
+package wave
+
+import (
+	"io"
+
+	"github.com/bake/wave/riff"
+	"github.com/pkg/errors"
+)
+
+// Errors returned if a file exceeds the Limits of a Reader. They are wrapped in
+// a *ValidationError and can be tested for with errors.Is.
+var (
+	ErrChunkTooLarge  = errors.New("chunk too large")
+	ErrTooManySamples = errors.New("too many samples")
+	ErrTooManyChunks  = riff.ErrTooManyChunks
+)
+
+// Limits restricts the resources a Reader may use for a file, which protects
+// against crafted files. Zero values mean no limit.
+type Limits struct {
+	MaxChunkSize int64 // Size of chunks that are read into memory, like bext and cue chunks.
+	MaxSamples   int64 // Samples read from all data and silence chunks.
+	MaxChunks    int   // Chunks, including those in lists.
+}
+
+// DefaultLimits are reasonable limits for files from untrusted sources.
+var DefaultLimits = Limits{
+	MaxChunkSize: 1 << 20,
+	MaxSamples:   1 << 32,
+	MaxChunks:    1 << 16,
+}
+
+// Limit makes the reader fail if a file exceeds the given limits.
+func Limit(l Limits) ReaderOption {
+	return func(wavr *Reader) { wavr.limits = l }
+}
+
+// buffer returns the data of the current chunk of rr, which is about to be
+// read into memory. It fails if the chunk exceeds the maximum chunk size.
+func (wavr *Reader) buffer(rr *riff.Reader) (io.Reader, error) {
+	id, size, data := rr.Chunk()
+	max := wavr.limits.MaxChunkSize
+	if max <= 0 {
+		return data, nil
+	}
+	if size > max {
+		return nil, &ValidationError{rr.Offset(), id,
+			errors.Wrapf(ErrChunkTooLarge, "%d bytes, at most %d allowed", size, max)}
+	}
+	// Chunks of an unknown size are cut off.
+	return io.LimitReader(data, max), nil
+}
+
+// maxBytes returns the number of bytes of samples that may be read or -1 if
+// there is no limit.
+func (wavr *Reader) maxBytes() int64 {
+	if wavr.limits.MaxSamples <= 0 {
+		return -1
+	}
+	return wavr.limits.MaxSamples * int64(wavr.Format.ContainerSize())
+}
+
+// consume counts n bytes read from the current chunk. It fails once more
+// samples have been read than allowed.
+func (wavr *Reader) consume(n int64) error {
+	wavr.n += n
+	wavr.total += n
+	if max := wavr.maxBytes(); max < 0 || wavr.total <= max {
+		return nil
+	}
+	id, _, _ := wavr.chunks().Chunk()
+	wavr.err = &ValidationError{wavr.chunks().Offset(), id,
+		errors.Wrapf(ErrTooManySamples, "more than %d", wavr.limits.MaxSamples)}
+	return wavr.err
+}
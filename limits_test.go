@@ -0,0 +1,110 @@
+package wave_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+func TestLimits(t *testing.T) {
+	tt := []struct {
+		name   string
+		limits wave.Limits
+		err    error
+	}{
+		{"none", wave.Limits{}, nil},
+		{"default", wave.DefaultLimits, nil},
+		{"samples", wave.Limits{MaxSamples: 22}, nil},
+		{"too many samples", wave.Limits{MaxSamples: 21}, wave.ErrTooManySamples},
+		{"chunks", wave.Limits{MaxChunks: 5}, nil},
+		{"too many chunks", wave.Limits{MaxChunks: 4}, wave.ErrTooManyChunks},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, read := range []func(wavr *wave.Reader) error{
+				func(wavr *wave.Reader) error { _, err := wavr.Samples(); return err },
+				func(wavr *wave.Reader) error { _, err := ioutil.ReadAll(wavr); return err },
+				func(wavr *wave.Reader) error { _, err := wavr.WriteTo(ioutil.Discard); return err },
+			} {
+				wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()), wave.Limit(tc.limits))
+				if err != nil {
+					t.Fatalf("could not create wave reader: %v", err)
+				}
+				err = read(wavr)
+				if tc.err == nil && err != nil {
+					t.Fatalf("could not read samples: %v", err)
+				}
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error %v, got %v", tc.err, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLimitsRead(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()), wave.Limit(wave.Limits{MaxSamples: 21}))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	data, err := ioutil.ReadAll(wavr)
+	if !errors.Is(err, wave.ErrTooManySamples) {
+		t.Fatalf("expected error %v, got %v", wave.ErrTooManySamples, err)
+	}
+	if len(data) != 44 {
+		t.Fatalf("expected the 44 bytes read to be returned, got %d", len(data))
+	}
+}
+
+func TestLimitsChunkSize(t *testing.T) {
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, wave.Format{
+		AudioFormat:   1,
+		NumChans:      1,
+		SampleRate:    8000,
+		ByteRate:      16000,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+	}, wave.BextChunk(&wave.Bext{CodingHistory: "A=PCM,F=8000,W=16,M=mono\r\n"}), wave.CompactSilence(1))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples([]int{1, 0, 0, 2}); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	wavw.SetCues([]wave.Cue{{ID: 1, Frame: 1}, {ID: 2, Frame: 2}})
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	body, _ := ioutil.ReadAll(ws.Reader())
+
+	wavr, err := wave.NewReader(bytes.NewReader(body), wave.Limit(wave.Limits{MaxChunkSize: 600}))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	var verr *wave.ValidationError
+	if _, err := wavr.Bext(); !errors.Is(err, wave.ErrChunkTooLarge) || !errors.As(err, &verr) || verr.Chunk != "bext" {
+		t.Fatalf("expected bext chunk to be too large, got %v", err)
+	}
+	wavr, err = wave.NewReader(bytes.NewReader(body), wave.Limit(wave.Limits{MaxChunkSize: 40}))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if _, err := wavr.Cues(); !errors.Is(err, wave.ErrChunkTooLarge) {
+		t.Fatalf("expected cue chunk to be too large, got %v", err)
+	}
+	wavr, err = wave.NewReader(bytes.NewReader(body), wave.Limit(wave.Limits{MaxChunkSize: 1000, MaxSamples: 4}))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if samples, err := wavr.Samples(); err != nil || len(samples) != 4 {
+		t.Fatalf("expected 4 samples, got %v: %v", samples, err)
+	}
+	if _, err := wavr.Cues(); err != nil {
+		t.Fatalf("could not read cues: %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+// Package loudness measures the loudness of WAVE files according to ITU-R
+// BS.1770 and EBU R128.
+package loudness
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/analysis"
+	"github.com/pkg/errors"
+)
+
+const (
+	momentaryBlocks = 4  // 400ms
+	shortTermBlocks = 30 // 3s
+	absoluteGate    = -70
+	relativeGate    = -10 // Relative gate of the integrated loudness.
+	rangeGate       = -20 // Relative gate of the loudness range.
+)
+
+// Weights returns the weights of chans channels in their usual WAVE order.
+// The surround channels of 5.1 are weighted by +1.5 dB and the LFE channel is
+// ignored.
+func Weights(chans int) []float64 {
+	weights := make([]float64, chans)
+	for i := range weights {
+		weights[i] = 1
+	}
+	if chans == 6 {
+		// L, R, C, LFE, Ls, Rs
+		weights[3] = 0
+		weights[4] = 1.41
+		weights[5] = 1.41
+	}
+	return weights
+}
+
+// Result holds the loudness of a signal. Loudness values of signals that are
+// too short or too quiet are -Inf.
+type Result struct {
+	Integrated   float64   // Integrated loudness in LUFS.
+	Range        float64   // Loudness range in LU.
+	TruePeak     float64   // Maximum true peak of all channels in dBTP.
+	MaxMomentary float64   // Maximum momentary loudness in LUFS.
+	MaxShortTerm float64   // Maximum short-term loudness in LUFS.
+	Momentary    []float64 // Momentary loudness every 100ms in LUFS.
+	ShortTerm    []float64 // Short-term loudness every 100ms in LUFS.
+}
+
+// Meter measures the loudness of interleaved samples.
+type Meter struct {
+	weights   []float64
+	filters   [][2]biquad
+	truePeak  *analysis.TruePeak
+	size      int       // Frames per 100ms block.
+	frames    int       // Frames in the current block.
+	sums      []float64 // Sums of squares of the current block per channel.
+	blocks    []float64 // Weighted mean squares of all 100ms blocks.
+	momentary []float64 // Mean squares of 400ms every 100ms.
+	shortTerm []float64 // Mean squares of 3s every 100ms.
+}
+
+// NewMeter creates a meter for samples of the given format. If weights is nil,
+// the default weights of its number of channels are used, otherwise there has
+// to be one per channel.
+func NewMeter(format wave.Format, weights []float64) (*Meter, error) {
+	if err := format.CheckFrames(); err != nil {
+		return nil, err
+	}
+	chans := int(format.NumChans)
+	if weights == nil {
+		weights = Weights(chans)
+	}
+	if len(weights) != chans {
+		return nil, errors.Errorf("expected %d weights, got %d", chans, len(weights))
+	}
+	truePeak, err := analysis.NewTruePeak(chans, 4)
+	if err != nil {
+		return nil, err
+	}
+	m := &Meter{
+		weights:  weights,
+		filters:  make([][2]biquad, chans),
+		truePeak: truePeak,
+		size:     int(math.Round(float64(format.SampleRate) / 10)),
+		sums:     make([]float64, chans),
+	}
+	for c := range m.filters {
+		shelf, highPass := kWeighting(float64(format.SampleRate))
+		m.filters[c] = [2]biquad{shelf, highPass}
+	}
+	return m, nil
+}
+
+// Process measures interleaved samples. Incomplete frames are ignored.
+func (m *Meter) Process(samples []float64) {
+	chans := len(m.filters)
+	m.truePeak.Process(samples)
+	for i := 0; i+chans <= len(samples); i += chans {
+		for c, v := range samples[i : i+chans] {
+			f := &m.filters[c]
+			v = f[1].process(f[0].process(v))
+			m.sums[c] += v * v
+		}
+		if m.frames++; m.frames == m.size {
+			m.endBlock()
+		}
+	}
+}
+
+// endBlock finishes the current 100ms block.
+func (m *Meter) endBlock() {
+	var z float64
+	for c, sum := range m.sums {
+		z += m.weights[c] * sum / float64(m.frames)
+		m.sums[c] = 0
+	}
+	m.frames = 0
+	m.blocks = append(m.blocks, z)
+	if n := len(m.blocks); n >= momentaryBlocks {
+		m.momentary = append(m.momentary, mean(m.blocks[n-momentaryBlocks:]))
+	}
+	if n := len(m.blocks); n >= shortTermBlocks {
+		m.shortTerm = append(m.shortTerm, mean(m.blocks[n-shortTermBlocks:]))
+	}
+}
+
+// Result returns the loudness of all samples processed so far. An incomplete
+// block at the end is ignored.
+func (m *Meter) Result() *Result {
+	r := &Result{
+		Integrated:   lufs(gatedMean(m.momentary, relativeGate)),
+		Range:        loudnessRange(m.shortTerm),
+		TruePeak:     math.Inf(-1),
+		MaxMomentary: math.Inf(-1),
+		MaxShortTerm: math.Inf(-1),
+		Momentary:    make([]float64, len(m.momentary)),
+		ShortTerm:    make([]float64, len(m.shortTerm)),
+	}
+	for c := range m.filters {
+		r.TruePeak = math.Max(r.TruePeak, analysis.DB(m.truePeak.Peak(c)))
+	}
+	for i, z := range m.momentary {
+		r.Momentary[i] = lufs(z)
+		r.MaxMomentary = math.Max(r.MaxMomentary, r.Momentary[i])
+	}
+	for i, z := range m.shortTerm {
+		r.ShortTerm[i] = lufs(z)
+		r.MaxShortTerm = math.Max(r.MaxShortTerm, r.ShortTerm[i])
+	}
+	return r
+}
+
+// lufs converts a weighted mean square to LUFS.
+func lufs(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
+
+// mean returns the arithmetic mean of zs.
+func mean(zs []float64) float64 {
+	var sum float64
+	for _, z := range zs {
+		sum += z
+	}
+	return sum / float64(len(zs))
+}
+
+// gate returns the blocks louder than threshold LUFS.
+func gate(zs []float64, threshold float64) []float64 {
+	var gated []float64
+	for _, z := range zs {
+		if lufs(z) > threshold {
+			gated = append(gated, z)
+		}
+	}
+	return gated
+}
+
+// gatedMean returns the mean of the blocks above the absolute gate and the
+// relative gate below their mean.
+func gatedMean(zs []float64, relative float64) float64 {
+	zs = gate(zs, absoluteGate)
+	if len(zs) == 0 {
+		return 0
+	}
+	return mean(gate(zs, lufs(mean(zs))+relative))
+}
+
+// loudnessRange returns the difference between the 10th and 95th percentile of
+// the gated short-term loudness as specified by EBU Tech 3342.
+func loudnessRange(zs []float64) float64 {
+	zs = gate(zs, absoluteGate)
+	if len(zs) == 0 {
+		return 0
+	}
+	zs = gate(zs, lufs(mean(zs))+rangeGate)
+	if len(zs) == 0 {
+		return 0
+	}
+	sort.Float64s(zs)
+	percentile := func(p float64) float64 {
+		return lufs(zs[int(math.Round(p*float64(len(zs)-1)))])
+	}
+	return percentile(0.95) - percentile(0.1)
+}
+
+// Measure reads all samples from r until EOF or until ctx is done and returns
+// their loudness.
+func Measure(ctx context.Context, r *wave.Reader) (*Result, error) {
+	m, err := NewMeter(r.Format, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = wave.ReadBlocks(ctx, r, func(block []float64) error {
+		m.Process(block)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.Result(), nil
+}
+
+// SetBext stores the loudness in the loudness fields of a bext chunk and
+// upgrades it to version 2. Values that could not be measured are marked as
+// unset.
+func (r *Result) SetBext(b *wave.Bext) {
+	field := func(v float64) int16 {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return wave.LoudnessUnset
+		}
+		v = math.Round(v * 100)
+		if v > math.MaxInt16-1 {
+			v = math.MaxInt16 - 1
+		}
+		if v < math.MinInt16 {
+			v = math.MinInt16
+		}
+		return int16(v)
+	}
+	if b.Version < 2 {
+		b.Version = 2
+	}
+	b.LoudnessValue = field(r.Integrated)
+	b.LoudnessRange = field(r.Range)
+	b.MaxTruePeakLevel = field(r.TruePeak)
+	b.MaxMomentaryLoudness = field(r.MaxMomentary)
+	b.MaxShortTermLoudness = field(r.MaxShortTerm)
+}
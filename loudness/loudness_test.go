@@ -0,0 +1,140 @@
+package loudness_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/bake/wave/loudness"
+	"github.com/pkg/errors"
+)
+
+// sine returns interleaved frames of a 997 Hz sine with an amplitude per
+// channel.
+func sine(rate int, seconds float64, amps ...float64) []float64 {
+	var samples []float64
+	for i := 0; i < int(seconds*float64(rate)); i++ {
+		v := math.Sin(2 * math.Pi * 997 * float64(i) / float64(rate))
+		for _, amp := range amps {
+			samples = append(samples, amp*v)
+		}
+	}
+	return samples
+}
+
+func format(chans, rate int) wave.Format {
+	return wavetest.Format(chans, rate, 24)
+}
+
+func TestMeter(t *testing.T) {
+	tt := []struct {
+		name       string
+		chans      int
+		rate       int
+		samples    []float64
+		integrated string
+		lra        string
+		truePeak   string
+	}{
+		{"stereo", 2, 48000, sine(48000, 5, 0.1, 0.1), "-20.0", "0.0", "-20.0"},
+		{"mono", 1, 44100, sine(44100, 5, 0.1), "-23.0", "0.0", "-20.0"},
+		{"gated", 2, 48000, append(sine(48000, 10, 0.1, 0.1), make([]float64, 2*48000*10)...), "-20.1", "4.4", "-20.0"},
+		{"surround", 6, 48000, sine(48000, 5, 0, 0, 0, 1, 0.1, 0), "-21.5", "0.0", "0.0"},
+		{"silence", 2, 48000, make([]float64, 2*48000), "-Inf", "0.0", "-Inf"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := loudness.NewMeter(format(tc.chans, tc.rate), nil)
+			if err != nil {
+				t.Fatalf("could not create meter: %v", err)
+			}
+			m.Process(tc.samples)
+			r := m.Result()
+			if got := fmt.Sprintf("%.1f", r.Integrated); got != tc.integrated {
+				t.Fatalf("expected integrated loudness to be %s, got %s", tc.integrated, got)
+			}
+			if got := fmt.Sprintf("%.1f", r.Range); got != tc.lra {
+				t.Fatalf("expected loudness range to be %s, got %s", tc.lra, got)
+			}
+			if got := fmt.Sprintf("%.1f", r.TruePeak); got != tc.truePeak {
+				t.Fatalf("expected true peak to be %s, got %s", tc.truePeak, got)
+			}
+		})
+	}
+}
+
+func TestMeterSeries(t *testing.T) {
+	m, err := loudness.NewMeter(format(2, 48000), nil)
+	if err != nil {
+		t.Fatalf("could not create meter: %v", err)
+	}
+	m.Process(sine(48000, 5, 0.1, 0.1))
+	r := m.Result()
+	// One value every 100ms after the first complete window.
+	if len(r.Momentary) != 47 {
+		t.Fatalf("expected 47 momentary values, got %d", len(r.Momentary))
+	}
+	if len(r.ShortTerm) != 21 {
+		t.Fatalf("expected 21 short-term values, got %d", len(r.ShortTerm))
+	}
+	if got := fmt.Sprintf("%.1f %.1f", r.MaxMomentary, r.MaxShortTerm); got != "-20.0 -20.0" {
+		t.Fatalf("expected maximum loudness to be -20.0 -20.0, got %s", got)
+	}
+}
+
+func TestMeterNoChannels(t *testing.T) {
+	if _, err := loudness.NewMeter(format(0, 48000), nil); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
+
+func TestMeterWeights(t *testing.T) {
+	if _, err := loudness.NewMeter(format(6, 48000), []float64{1, 1}); err == nil {
+		t.Fatalf("expected 2 weights for 6 channels to fail")
+	}
+	m, err := loudness.NewMeter(format(2, 48000), []float64{1, 0})
+	if err != nil {
+		t.Fatalf("could not create meter: %v", err)
+	}
+	m.Process(sine(48000, 5, 0.1, 1))
+	if got := fmt.Sprintf("%.1f", m.Result().Integrated); got != "-23.0" {
+		t.Fatalf("expected integrated loudness of the first channel to be -23.0, got %s", got)
+	}
+}
+
+func TestLoudnessRange(t *testing.T) {
+	// 20s at -20 LUFS followed by 20s at -30 LUFS.
+	samples := append(sine(48000, 20, 0.1, 0.1), sine(48000, 20, 0.0316, 0.0316)...)
+	m, err := loudness.NewMeter(format(2, 48000), nil)
+	if err != nil {
+		t.Fatalf("could not create meter: %v", err)
+	}
+	m.Process(samples)
+	r := m.Result()
+	if math.Abs(r.Range-10) > 0.2 {
+		t.Fatalf("expected loudness range to be about 10 LU, got %.2f", r.Range)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	wavr := wavetest.Floats(t, format(2, 48000), sine(48000, 2, 0.1, 0.1))
+	r, err := loudness.Measure(context.Background(), wavr)
+	if err != nil {
+		t.Fatalf("could not measure loudness: %v", err)
+	}
+	var bext wave.Bext
+	r.SetBext(&bext)
+	got := fmt.Sprint(bext.Version, bext.LoudnessValue, bext.LoudnessRange, bext.MaxTruePeakLevel, bext.MaxShortTermLoudness)
+	if got != "2 -2000 0 -2000 32767" {
+		t.Fatalf("expected bext loudness to be 2 -2000 0 -2000 32767, got %s", got)
+	}
+}
+
+func TestMeasureNoChannels(t *testing.T) {
+	if _, err := loudness.Measure(context.Background(), wavetest.NoChannels(t)); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
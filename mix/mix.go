@@ -0,0 +1,169 @@
+// Package mix maps, downmixes and upmixes the channels of WAVE files.
+package mix
+
+import (
+	"context"
+	"math"
+
+	"github.com/bake/wave"
+	"github.com/pkg/errors"
+)
+
+// Matrix holds the gain of each input channel in each output channel, indexed
+// by the output channel first.
+type Matrix [][]float64
+
+// Inputs returns the number of input channels.
+func (m Matrix) Inputs() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+// Outputs returns the number of output channels.
+func (m Matrix) Outputs() int {
+	return len(m)
+}
+
+// Process mixes interleaved input frames and appends the output frames to
+// dst. Incomplete frames are ignored.
+func (m Matrix) Process(dst, src []float64) []float64 {
+	in := m.Inputs()
+	for i := 0; i+in <= len(src); i += in {
+		frame := src[i : i+in]
+		for _, gains := range m {
+			var v float64
+			for c, gain := range gains {
+				v += gain * frame[c]
+			}
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+// Format returns the format of the output of the matrix applied to samples of
+// src, with the speaker positions in mask.
+func (m Matrix) Format(src wave.Format, mask uint32) wave.Format {
+	dst := src
+	dst.NumChans = uint16(m.Outputs())
+	dst.BlockAlign = dst.NumChans * uint16(src.ContainerSize())
+	dst.ByteRate = dst.SampleRate * uint32(dst.BlockAlign)
+	dst.ChannelMask = mask
+	return dst
+}
+
+// StereoToMono returns a matrix that averages both channels.
+func StereoToMono() Matrix {
+	return Matrix{{0.5, 0.5}}
+}
+
+// MonoToStereo returns a matrix that copies a single channel into two.
+func MonoToStereo() Matrix {
+	return Matrix{{1}, {1}}
+}
+
+// Downmix51 returns the downmix of 5.1 channels in the order L, R, C, LFE, Ls,
+// Rs to stereo as recommended by ITU-R BS.775. The LFE channel is dropped.
+func Downmix51() Matrix {
+	return Matrix{
+		{1, 0, math.Sqrt2 / 2, 0, math.Sqrt2 / 2, 0},
+		{0, 1, math.Sqrt2 / 2, 0, 0, math.Sqrt2 / 2},
+	}
+}
+
+// Extract returns a matrix that selects channels out of inputs channels in the
+// given order. It can be used to extract or reorder channels.
+func Extract(inputs int, channels ...int) (Matrix, error) {
+	m := make(Matrix, len(channels))
+	for i, c := range channels {
+		if c < 0 || c >= inputs {
+			return nil, errors.Errorf("channel %d out of range of %d channels", c, inputs)
+		}
+		m[i] = make([]float64, inputs)
+		m[i][c] = 1
+	}
+	return m, nil
+}
+
+// Remap returns a matrix that reorders the channels of src to the speakers of
+// mask. Speakers missing in src are silent and channels of src without a
+// speaker in mask are dropped.
+func Remap(src wave.Format, mask uint32) Matrix {
+	dst := wave.Format{NumChans: uint16(bitCount(mask)), ChannelMask: mask}
+	in := src.Speakers()
+	m := make(Matrix, dst.NumChans)
+	for o, speaker := range dst.Speakers() {
+		m[o] = make([]float64, len(in))
+		for i, s := range in {
+			if s == speaker {
+				m[o][i] = 1
+			}
+		}
+	}
+	return m
+}
+
+// Stereo returns a matrix that downmixes src to stereo based on its speaker
+// positions. Center channels are added to both sides at -3 dB, as are
+// surround channels to their side. Low frequency channels are dropped. A single
+// channel is copied to both sides.
+func Stereo(src wave.Format) (Matrix, error) {
+	if src.NumChans == 1 {
+		return MonoToStereo(), nil
+	}
+	half := math.Sqrt2 / 2
+	m := Matrix{make([]float64, src.NumChans), make([]float64, src.NumChans)}
+	for i, speaker := range src.Speakers() {
+		switch speaker {
+		case wave.SpeakerFrontLeft, wave.SpeakerFrontLeftOfCenter:
+			m[0][i] = 1
+		case wave.SpeakerFrontRight, wave.SpeakerFrontRightOfCenter:
+			m[1][i] = 1
+		case wave.SpeakerFrontCenter, wave.SpeakerBackCenter:
+			m[0][i], m[1][i] = half, half
+		case wave.SpeakerBackLeft, wave.SpeakerSideLeft:
+			m[0][i] = half
+		case wave.SpeakerBackRight, wave.SpeakerSideRight:
+			m[1][i] = half
+		case wave.SpeakerLowFrequency:
+		default:
+			return nil, errors.Errorf("unknown position of channel %d", i)
+		}
+	}
+	return m, nil
+}
+
+// bitCount returns the number of set bits.
+func bitCount(v uint32) int {
+	n := 0
+	for ; v > 0; v &= v - 1 {
+		n++
+	}
+	return n
+}
+
+// Mix reads all samples from src until EOF or until ctx is done, applies the
+// matrix and writes them to dst.
+func Mix(ctx context.Context, dst *wave.Writer, src *wave.Reader, m Matrix) error {
+	if err := src.Format.CheckFrames(); err != nil {
+		return err
+	}
+	if m.Inputs() == 0 {
+		return errors.New("matrix has no input channels")
+	}
+	if m.Inputs() != int(src.Format.NumChans) {
+		return errors.Errorf("matrix expects %d channels, got %d", m.Inputs(), src.Format.NumChans)
+	}
+	if chans := dst.FrameFormat().NumChans; int(chans) != m.Outputs() {
+		return errors.Errorf("matrix returns %d channels, writer expects %d", m.Outputs(), chans)
+	}
+	out := make([]float64, 0, wave.BlockSize*m.Outputs())
+	return wave.ReadBlocks(ctx, src, func(block []float64) error {
+		if err := dst.Floats(m.Process(out[:0], block)); err != nil {
+			return errors.Wrap(err, "could not write samples")
+		}
+		return nil
+	})
+}
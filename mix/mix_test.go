@@ -0,0 +1,129 @@
+package mix_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/bake/wave/mix"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+func TestMatrix(t *testing.T) {
+	extract, err := mix.Extract(3, 2, 0)
+	if err != nil {
+		t.Fatalf("could not create matrix: %v", err)
+	}
+	surround := wave.Format{NumChans: 6}
+	stereo, err := mix.Stereo(surround)
+	if err != nil {
+		t.Fatalf("could not create matrix: %v", err)
+	}
+	sides := wave.Format{
+		NumChans:    4,
+		ChannelMask: wave.SpeakerFrontLeft | wave.SpeakerFrontRight | wave.SpeakerSideLeft | wave.SpeakerSideRight,
+	}
+	quad, err := mix.Stereo(sides)
+	if err != nil {
+		t.Fatalf("could not create matrix: %v", err)
+	}
+	tt := []struct {
+		name string
+		m    mix.Matrix
+		in   []float64
+		out  string
+	}{
+		{"stereo to mono", mix.StereoToMono(), []float64{0.5, 0.25, -1, 1}, "[0.375 0.000]"},
+		{"mono to stereo", mix.MonoToStereo(), []float64{0.5, -0.25}, "[0.500 0.500 -0.250 -0.250]"},
+		{"5.1 to stereo", mix.Downmix51(), []float64{0.5, 0.25, 0, 1, 0.5, 0}, "[0.854 0.250]"},
+		{"5.1 by mask", stereo, []float64{0.5, 0.25, 0, 1, 0.5, 0}, "[0.854 0.250]"},
+		{"side channels", quad, []float64{0, 0.25, 0.5, 0}, "[0.354 0.250]"},
+		{"extract", extract, []float64{0.1, 0.2, 0.3, 0.4, 0.5}, "[0.300 0.100]"},
+		{"remap", mix.Remap(surround, wave.SpeakerFrontCenter|wave.SpeakerSideLeft), []float64{1, 2, 3, 4, 5, 6}, "[3.000 0.000]"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fmt.Sprintf("%.3f", tc.m.Process(nil, tc.in)); got != tc.out {
+				t.Fatalf("expected output to be %s, got %s", tc.out, got)
+			}
+		})
+	}
+	if _, err := mix.Extract(2, 2); err == nil {
+		t.Fatalf("expected channel 2 of 2 to be out of range")
+	}
+}
+
+func TestMix(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      2,
+		SampleRate:    8000,
+		ByteRate:      32000,
+		BlockAlign:    4,
+		BitsPerSample: 16,
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples([]int{100, 200, -100, -300, 7, 8}); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	wavr, err := wave.NewReader(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+
+	m := mix.StereoToMono()
+	out := m.Format(format, wave.SpeakerFrontCenter)
+	ws = &writerseeker.WriterSeeker{}
+	wavw, err = wave.NewWriter(ws, out)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := mix.Mix(context.Background(), wavw, wavr, m); err != nil {
+		t.Fatalf("could not mix samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	wavr, err = wave.NewReader(ws.Reader(), wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if wavr.Format != out {
+		t.Fatalf("expected format to be %v, got %v", out, wavr.Format)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if exp := "[150 -200 8]"; fmt.Sprint(samples) != exp {
+		t.Fatalf("expected samples to be %s, got %v", exp, samples)
+	}
+}
+
+func TestMixChannels(t *testing.T) {
+	format := wavetest.Format(2, 8000, 16)
+	wavw, err := wave.NewWriter(&writerseeker.WriterSeeker{}, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	src := wavetest.Samples(t, format, []int{100, 200})
+	if err := mix.Mix(context.Background(), wavw, src, mix.StereoToMono()); err == nil {
+		t.Fatalf("expected a writer with a different number of channels to be rejected")
+	}
+	if err := mix.Mix(context.Background(), wavw, wavetest.NoChannels(t), mix.Matrix{}); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+	if err := mix.Mix(context.Background(), wavw, src, mix.Matrix{{}, {}}); err == nil {
+		t.Fatalf("expected a matrix without inputs to be rejected")
+	}
+}
@@ -0,0 +1,218 @@
+package mix
+
+import (
+	"context"
+	"io"
+
+	"github.com/bake/wave"
+	"github.com/pkg/errors"
+)
+
+// ChannelFormat returns the format of a single channel c of src. It keeps the
+// speaker position of the channel if src has a channel mask.
+func ChannelFormat(src wave.Format, c int) wave.Format {
+	var mask uint32
+	if src.ChannelMask != 0 {
+		mask = src.Speakers()[c]
+	}
+	dst := src
+	dst.NumChans = 1
+	dst.BlockAlign = src.BlockAlign / src.NumChans
+	dst.ByteRate = dst.SampleRate * uint32(dst.BlockAlign)
+	dst.ChannelMask = mask
+	return dst
+}
+
+// JoinFormat returns the format of the channels of all formats joined into
+// one. They have to share their audio format, sample rate, bit depth and
+// container size. Their channel masks are combined if none overlap.
+func JoinFormat(formats ...wave.Format) (wave.Format, error) {
+	if len(formats) == 0 {
+		return wave.Format{}, errors.New("no formats to join")
+	}
+	dst := formats[0]
+	dst.NumChans = 0
+	dst.ChannelMask = 0
+	masked := true
+	for i, f := range formats {
+		if f.AudioFormat != dst.AudioFormat {
+			return wave.Format{}, errors.Errorf("audio format of file %d is %d, expected %d", i, f.AudioFormat, dst.AudioFormat)
+		}
+		if f.SampleRate != dst.SampleRate {
+			return wave.Format{}, errors.Errorf("sample rate of file %d is %d Hz, expected %d Hz", i, f.SampleRate, dst.SampleRate)
+		}
+		if f.BitsPerSample != dst.BitsPerSample {
+			return wave.Format{}, errors.Errorf("file %d has %d bits per sample, expected %d", i, f.BitsPerSample, dst.BitsPerSample)
+		}
+		if f.ContainerSize() != dst.ContainerSize() {
+			return wave.Format{}, errors.Errorf("file %d has %d bytes per sample, expected %d", i, f.ContainerSize(), dst.ContainerSize())
+		}
+		if f.ChannelMask == 0 || dst.ChannelMask&f.ChannelMask != 0 {
+			masked = false
+		}
+		dst.NumChans += f.NumChans
+		dst.ChannelMask |= f.ChannelMask
+	}
+	if !masked {
+		dst.ChannelMask = 0
+	}
+	dst.BlockAlign = dst.NumChans * uint16(dst.ContainerSize())
+	dst.ByteRate = dst.SampleRate * uint32(dst.BlockAlign)
+	return dst, nil
+}
+
+// Split copies each channel of src into its own writer until EOF or until ctx
+// is done. The writers have to use the formats returned by ChannelFormat.
+// Samples are copied without decoding them.
+func Split(ctx context.Context, dsts []*wave.Writer, src *wave.Reader) error {
+	if err := src.Format.CheckFrames(); err != nil {
+		return err
+	}
+	if len(dsts) != int(src.Format.NumChans) {
+		return errors.Errorf("expected %d writers, got %d", src.Format.NumChans, len(dsts))
+	}
+	for c, dst := range dsts {
+		if dst.FrameFormat() != ChannelFormat(src.Format, c) {
+			return errors.Errorf("format of writer %d does not match channel %d", c, c)
+		}
+	}
+	frameSize := int(src.Format.BlockAlign)
+	sampleSize := frameSize / len(dsts)
+	buf := make([]byte, wave.BlockSize*frameSize)
+	out := make([][]byte, len(dsts))
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(src, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return errors.Wrap(err, "could not read samples")
+		}
+		for c := range out {
+			out[c] = out[c][:0]
+		}
+		for i := 0; i+frameSize <= n; i += frameSize {
+			for c := range out {
+				out[c] = append(out[c], buf[i+c*sampleSize:i+(c+1)*sampleSize]...)
+			}
+		}
+		for c, dst := range dsts {
+			if _, err := dst.Write(out[c]); err != nil {
+				return errors.Wrapf(err, "could not write channel %d", c)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// Join interleaves the channels of all srcs into dst until EOF of the longest
+// one or until ctx is done. dst has to use the format returned by JoinFormat.
+// Sources are aligned by the time reference of their bext chunks, sources
+// without one start with the earliest. Missing samples are silent. Samples are
+// copied without decoding them. It returns the time reference of the first
+// frame of dst.
+func Join(ctx context.Context, dst *wave.Writer, srcs []*wave.Reader) (uint64, error) {
+	formats := make([]wave.Format, len(srcs))
+	for i, src := range srcs {
+		if err := src.Format.CheckFrames(); err != nil {
+			return 0, errors.Wrapf(err, "file %d", i)
+		}
+		formats[i] = src.Format
+	}
+	format, err := JoinFormat(formats...)
+	if err != nil {
+		return 0, err
+	}
+	if dst.FrameFormat() != format {
+		return 0, errors.New("format of writer does not match the joined files")
+	}
+	start, offsets, err := align(srcs)
+	if err != nil {
+		return 0, err
+	}
+	silence := wave.SilenceReader(formats[0])
+	inputs := make([]io.Reader, len(srcs))
+	bufs := make([][]byte, len(srcs))
+	frameSize := 0
+	for i, src := range srcs {
+		lead := io.LimitReader(silence, offsets[i]*int64(src.Format.BlockAlign))
+		inputs[i] = io.MultiReader(lead, src)
+		bufs[i] = make([]byte, wave.BlockSize*int(src.Format.BlockAlign))
+		frameSize += int(src.Format.BlockAlign)
+	}
+	out := make([]byte, 0, wave.BlockSize*frameSize)
+	done := make([]bool, len(srcs))
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		frames := 0
+		for i, r := range inputs {
+			n := 0
+			if !done[i] {
+				var err error
+				n, err = io.ReadFull(r, bufs[i])
+				if err == io.EOF || err == io.ErrUnexpectedEOF {
+					done[i] = true
+				} else if err != nil {
+					return 0, errors.Wrapf(err, "could not read file %d", i)
+				}
+			}
+			size := int(srcs[i].Format.BlockAlign)
+			n -= n % size
+			if _, err := io.ReadFull(silence, bufs[i][n:]); err != nil {
+				return 0, errors.Wrap(err, "could not fill silence")
+			}
+			if n/size > frames {
+				frames = n / size
+			}
+		}
+		if frames == 0 {
+			return start, nil
+		}
+		out = out[:0]
+		for f := 0; f < frames; f++ {
+			for i, buf := range bufs {
+				size := int(srcs[i].Format.BlockAlign)
+				out = append(out, buf[f*size:(f+1)*size]...)
+			}
+		}
+		if _, err := dst.Write(out); err != nil {
+			return 0, errors.Wrap(err, "could not write samples")
+		}
+	}
+}
+
+// align returns the earliest time reference of srcs and the number of frames
+// each one starts after it.
+func align(srcs []*wave.Reader) (uint64, []int64, error) {
+	refs := make([]uint64, len(srcs))
+	known := make([]bool, len(srcs))
+	start := uint64(0)
+	found := false
+	for i, src := range srcs {
+		bext, err := src.Bext()
+		if err != nil {
+			return 0, nil, errors.Wrapf(err, "could not read bext chunk of file %d", i)
+		}
+		if bext == nil {
+			continue
+		}
+		refs[i], known[i] = bext.TimeReference, true
+		if !found || refs[i] < start {
+			start, found = refs[i], true
+		}
+	}
+	offsets := make([]int64, len(srcs))
+	for i := range srcs {
+		if known[i] {
+			offsets[i] = int64(refs[i] - start)
+		}
+	}
+	return start, offsets, nil
+}
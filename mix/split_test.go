@@ -0,0 +1,153 @@
+package mix_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/bake/wave/mix"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+func mono(bits int) wave.Format {
+	return wavetest.Format(1, 8000, bits)
+}
+
+func TestSplit(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      3,
+		SampleRate:    8000,
+		ByteRate:      72000,
+		BlockAlign:    9,
+		BitsPerSample: 24,
+		ChannelMask:   wave.SpeakerFrontLeft | wave.SpeakerFrontRight | wave.SpeakerLowFrequency,
+	}
+	src := wavetest.Samples(t, format, []int{1, 2, 3, -1, -2, -3, 100000, 0, -100000})
+	var dsts []*wave.Writer
+	var wss []*writerseeker.WriterSeeker
+	for c := 0; c < 3; c++ {
+		ws := &writerseeker.WriterSeeker{}
+		wavw, err := wave.NewWriter(ws, mix.ChannelFormat(format, c))
+		if err != nil {
+			t.Fatalf("could not create wave writer: %v", err)
+		}
+		dsts = append(dsts, wavw)
+		wss = append(wss, ws)
+	}
+	if err := mix.Split(context.Background(), dsts, src); err != nil {
+		t.Fatalf("could not split file: %v", err)
+	}
+	tt := []struct {
+		mask    uint32
+		samples string
+	}{
+		{wave.SpeakerFrontLeft, "[1 -1 100000]"},
+		{wave.SpeakerFrontRight, "[2 -2 0]"},
+		{wave.SpeakerLowFrequency, "[3 -3 -100000]"},
+	}
+	for c, tc := range tt {
+		if err := dsts[c].Close(); err != nil {
+			t.Fatalf("could not close wave writer: %v", err)
+		}
+		wavr, err := wave.NewReader(wss[c].Reader(), wave.Strict())
+		if err != nil {
+			t.Fatalf("could not create wave reader: %v", err)
+		}
+		if wavr.Format.ChannelMask != tc.mask {
+			t.Fatalf("expected channel mask of channel %d to be %x, got %x", c, tc.mask, wavr.Format.ChannelMask)
+		}
+		samples, err := wavr.Samples()
+		if err != nil {
+			t.Fatalf("could not read samples: %v", err)
+		}
+		if fmt.Sprint(samples) != tc.samples {
+			t.Fatalf("expected samples of channel %d to be %s, got %v", c, tc.samples, samples)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	srcs := []*wave.Reader{
+		wavetest.Samples(t, mono(8), []int{1, 2, 3}, wave.BextChunk(&wave.Bext{TimeReference: 102})),
+		wavetest.Samples(t, mono(8), []int{4, 5, 6, 7, 8}, wave.BextChunk(&wave.Bext{TimeReference: 100})),
+		wavetest.Samples(t, mono(8), []int{9}),
+	}
+	formats := []wave.Format{srcs[0].Format, srcs[1].Format, srcs[2].Format}
+	format, err := mix.JoinFormat(formats...)
+	if err != nil {
+		t.Fatalf("could not join formats: %v", err)
+	}
+	var start uint64
+	ws := wavetest.Write(t, format, func(wavw *wave.Writer) (err error) {
+		start, err = mix.Join(context.Background(), wavw, srcs)
+		return err
+	})
+	if start != 100 {
+		t.Fatalf("expected time reference to be 100, got %d", start)
+	}
+	samples := wavetest.ReadSamples(t, ws)
+	exp := "[128 4 9 128 5 128 1 6 128 2 7 128 3 8 128]"
+	if fmt.Sprint(samples) != exp {
+		t.Fatalf("expected samples to be\n%s, got\n%v", exp, samples)
+	}
+
+	if _, err := mix.JoinFormat(mono(8), mono(16)); err == nil {
+		t.Fatalf("expected formats with different bit depths not to be joined")
+	}
+	wide := mono(32)
+	wide.BitsPerSample = 24
+	if _, err := mix.JoinFormat(mono(24), wide); err == nil {
+		t.Fatalf("expected formats with different containers not to be joined")
+	}
+	float := mono(32)
+	float.AudioFormat = 3
+	if _, err := mix.JoinFormat(mono(32), float); err == nil {
+		t.Fatalf("expected formats with different audio formats not to be joined")
+	}
+}
+
+func TestSplitFormat(t *testing.T) {
+	format := wavetest.Format(2, 8000, 16)
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, mono(8))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	dsts := []*wave.Writer{wavw, wavw}
+	src := wavetest.Samples(t, format, []int{1, 2})
+	if err := mix.Split(context.Background(), dsts, src); err == nil {
+		t.Fatalf("expected writers with a different format to be rejected")
+	}
+}
+
+func TestJoinFormat(t *testing.T) {
+	srcs := []*wave.Reader{
+		wavetest.Samples(t, mono(8), []int{1, 2}),
+		wavetest.Samples(t, mono(8), []int{3, 4}),
+	}
+	wavw, err := wave.NewWriter(&writerseeker.WriterSeeker{}, wavetest.Format(2, 8000, 16))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if _, err := mix.Join(context.Background(), wavw, srcs); err == nil {
+		t.Fatalf("expected a writer with a different format to be rejected")
+	}
+}
+
+func TestNoChannels(t *testing.T) {
+	if err := mix.Split(context.Background(), nil, wavetest.NoChannels(t)); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+	wavw, err := wave.NewWriter(&writerseeker.WriterSeeker{}, mono(8))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	srcs := []*wave.Reader{wavetest.Samples(t, mono(8), []int{1}), wavetest.NoChannels(t)}
+	if _, err := mix.Join(context.Background(), wavw, srcs); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
@@ -0,0 +1,101 @@
+// Package normalize changes the level of WAVE files to a target peak or
+// loudness.
+package normalize
+
+import (
+	"context"
+	"math"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/analysis"
+	"github.com/bake/wave/loudness"
+	"github.com/pkg/errors"
+)
+
+// Mode selects what is measured.
+type Mode int
+
+// Modes of normalization.
+const (
+	Peak     Mode = iota // Sample peak in dBFS.
+	TruePeak             // True peak in dBTP.
+	Loudness             // Integrated loudness in LUFS.
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Peak:
+		return "peak"
+	case TruePeak:
+		return "true peak"
+	case Loudness:
+		return "loudness"
+	}
+	return "unknown"
+}
+
+// Target is the level to normalize to.
+type Target struct {
+	Mode  Mode
+	Level float64
+}
+
+// Measure reads all samples from src and returns their level.
+func Measure(ctx context.Context, src *wave.Reader, mode Mode) (float64, error) {
+	if mode == Loudness {
+		r, err := loudness.Measure(ctx, src)
+		if err != nil {
+			return 0, err
+		}
+		return r.Integrated, nil
+	}
+	report, err := analysis.Analyze(ctx, src, analysis.Options{})
+	if err != nil {
+		return 0, err
+	}
+	level := math.Inf(-1)
+	for _, ch := range report.Channels {
+		peak := ch.Peak
+		if mode == TruePeak {
+			peak = ch.TruePeak
+		}
+		level = math.Max(level, analysis.DB(peak))
+	}
+	return level, nil
+}
+
+// Apply measures the level of src, rewinds it and writes its samples amplified
+// to the target level into dst. src has to be seekable. Samples exceeding full
+// scale are clipped. Pass wave.Dither to the writer when reducing the bit
+// depth. It returns the applied gain in dB.
+func Apply(ctx context.Context, dst *wave.Writer, src *wave.Reader, target Target) (float64, error) {
+	level, err := Measure(ctx, src, target.Mode)
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not measure %s", target.Mode)
+	}
+	if math.IsInf(level, -1) {
+		return 0, errors.Errorf("could not normalize silence")
+	}
+	if err := src.Rewind(); err != nil {
+		return 0, errors.Wrap(err, "could not rewind")
+	}
+	gain := target.Level - level
+	if err := Gain(ctx, dst, src, gain); err != nil {
+		return 0, err
+	}
+	return gain, nil
+}
+
+// Gain writes all samples from src amplified by gain dB into dst.
+func Gain(ctx context.Context, dst *wave.Writer, src *wave.Reader, gain float64) error {
+	factor := analysis.FromDB(gain)
+	return wave.ReadBlocks(ctx, src, func(block []float64) error {
+		for i := range block {
+			block[i] *= factor
+		}
+		if err := dst.Floats(block); err != nil {
+			return errors.Wrap(err, "could not write samples")
+		}
+		return nil
+	})
+}
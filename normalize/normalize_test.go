@@ -0,0 +1,88 @@
+package normalize_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/bake/wave/normalize"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+func format(bits int) wave.Format {
+	return wavetest.Format(2, 48000, bits)
+}
+
+// sine returns a reader of a 2 second stereo sine at 997 Hz.
+func sine(t *testing.T, amp float64) *wave.Reader {
+	var samples []float64
+	for i := 0; i < 2*48000; i++ {
+		v := amp * math.Sin(2*math.Pi*997*float64(i)/48000)
+		samples = append(samples, v, v)
+	}
+	return wavetest.Floats(t, format(24), samples)
+}
+
+func TestApply(t *testing.T) {
+	tt := []struct {
+		target normalize.Target
+		bits   int
+		gain   string
+	}{
+		{normalize.Target{Mode: normalize.Peak, Level: -6}, 24, "14.0"},
+		{normalize.Target{Mode: normalize.TruePeak, Level: -1}, 16, "19.0"},
+		{normalize.Target{Mode: normalize.Loudness, Level: -16}, 16, "4.0"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.target.Mode.String(), func(t *testing.T) {
+			src := sine(t, 0.1)
+			var opts []wave.WriterOption
+			if tc.bits < 24 {
+				opts = append(opts, wave.Dither())
+			}
+			var gain float64
+			ws := wavetest.Write(t, format(tc.bits), func(wavw *wave.Writer) (err error) {
+				gain, err = normalize.Apply(context.Background(), wavw, src, tc.target)
+				return err
+			}, opts...)
+			if got := fmt.Sprintf("%.1f", gain); got != tc.gain {
+				t.Fatalf("expected gain to be %s dB, got %s dB", tc.gain, got)
+			}
+			level, err := normalize.Measure(context.Background(), wavetest.Read(t, ws), tc.target.Mode)
+			if err != nil {
+				t.Fatalf("could not measure level: %v", err)
+			}
+			if math.Abs(level-tc.target.Level) > 0.05 {
+				t.Fatalf("expected level to be %.1f, got %.2f", tc.target.Level, level)
+			}
+		})
+	}
+}
+
+func TestApplySilence(t *testing.T) {
+	src := sine(t, 0)
+	wavw, err := wave.NewWriter(&writerseeker.WriterSeeker{}, format(24))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if _, err := normalize.Apply(context.Background(), wavw, src, normalize.Target{Level: -1}); err == nil {
+		t.Fatalf("expected silence not to be normalized")
+	}
+}
+
+func TestNoChannels(t *testing.T) {
+	wavw, err := wave.NewWriter(&writerseeker.WriterSeeker{}, format(24))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := normalize.Gain(context.Background(), wavw, wavetest.NoChannels(t), 6); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+	if _, err := normalize.Apply(context.Background(), wavw, wavetest.NoChannels(t), normalize.Target{Level: -1}); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
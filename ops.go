@@ -0,0 +1,113 @@
+package wave
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// progressInterval is the number of frames after which long running
+// operations check for cancellation and report their progress.
+const progressInterval = 4096
+
+// Progress is called periodically by long running operations with the number
+// of frames processed so far and the total number of frames, which is -1 if it
+// is unknown.
+type Progress func(frames, total int64)
+
+// Copy copies all samples from src to dst until EOF or until ctx is done. Both
+// have to share the same format. It returns the number of frames copied.
+func Copy(ctx context.Context, dst *Writer, src *Reader, progress Progress) (int64, error) {
+	if dst.fmt.NumChans != src.Format.NumChans ||
+		dst.fmt.SampleRate != src.Format.SampleRate ||
+		dst.fmt.BitsPerSample != src.Format.BitsPerSample {
+		return 0, errors.New("formats do not match")
+	}
+	offset := src.offset() - dst.offset()
+	return Analyze(ctx, src, func(frame []int) error {
+		for i := range frame {
+			frame[i] += offset
+		}
+		return dst.Samples(frame)
+	}, progress)
+}
+
+// Convert copies all samples from src to dst, scaling them to the bit depth of
+// dst, until EOF or until ctx is done. The number of channels and the sample
+// rate have to match. It returns the number of frames converted.
+func Convert(ctx context.Context, dst *Writer, src *Reader, progress Progress) (int64, error) {
+	if dst.fmt.NumChans != src.Format.NumChans {
+		return 0, errors.Errorf("can not convert %d to %d channels", src.Format.NumChans, dst.fmt.NumChans)
+	}
+	if dst.fmt.SampleRate != src.Format.SampleRate {
+		return 0, errors.Errorf("can not convert %d Hz to %d Hz", src.Format.SampleRate, dst.fmt.SampleRate)
+	}
+	from, to := src.Format.BitsPerSample, dst.fmt.BitsPerSample
+	return Analyze(ctx, src, func(frame []int) error {
+		for i, s := range frame {
+			frame[i] = convertSample(s+src.offset(), from, to) - dst.offset()
+		}
+		return dst.Samples(frame)
+	}, progress)
+}
+
+// convertSample scales a sample to another bit depth. 8 bit samples are
+// unsigned.
+func convertSample(s int, from, to uint16) int {
+	if from == 8 {
+		s -= 128
+	}
+	s = shiftSample(s, from, to)
+	if to == 8 {
+		s += 128
+	}
+	return s
+}
+
+// shiftSample scales a signed sample to another bit depth.
+func shiftSample(s int, from, to uint16) int {
+	if to > from {
+		return s << uint(to-from)
+	}
+	return s >> uint(from-to)
+}
+
+// Analyze calls fn with each frame of src until EOF, until fn returns an error
+// or until ctx is done. The frame is reused between calls. It returns the
+// number of frames processed.
+func Analyze(ctx context.Context, src *Reader, fn func(frame []int) error, progress Progress) (int64, error) {
+	total := int64(-1)
+	if progress != nil {
+		var err error
+		if total, err = src.NumFrames(); err != nil {
+			return 0, err
+		}
+	}
+	frame := make([]int, src.Format.NumChans)
+	var frames int64
+	for ; ; frames++ {
+		if frames%progressInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return frames, err
+			}
+			if progress != nil {
+				progress(frames, total)
+			}
+		}
+		err := src.frame(frame)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return frames, err
+		}
+		if err := fn(frame); err != nil {
+			return frames, err
+		}
+	}
+	if progress != nil {
+		progress(frames, total)
+	}
+	return frames, nil
+}
@@ -0,0 +1,115 @@
+package wave_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+)
+
+func TestCopy(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, wavr.Format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	var calls [][2]int64
+	progress := func(frames, total int64) { calls = append(calls, [2]int64{frames, total}) }
+	n, err := wave.Copy(context.Background(), wavw, wavr, progress)
+	if err != nil {
+		t.Fatalf("could not copy samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	if n != 11 {
+		t.Fatalf("expected 11 frames to be copied, got %d", n)
+	}
+	if fmt.Sprint(calls) != "[[0 11] [11 11]]" {
+		t.Fatalf("unexpected progress %v", calls)
+	}
+
+	wavr, err = wave.NewReader(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	out := []int{
+		0, 0, 5924, -3298, 4924, 5180, -1770, -1768,
+		-6348, -23005, -3524, -3548, -12783, 3354,
+		0, 0, 5924, -3298, 4924, 5180, -1770, -1768,
+	}
+	if fmt.Sprint(samples) != fmt.Sprint(out) {
+		t.Fatalf("expected samples to be\n%v, got\n%v", out, samples)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tt := []struct {
+		bps uint16
+		out []int
+	}{
+		{8, []int{128, 128, 151, 115, 147, 148, 121, 121}},
+		{24, []int{0, 0, 1516544, -844288, 1260544, 1326080, -453120, -452608}},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprint(tc.bps), func(t *testing.T) {
+			wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+			if err != nil {
+				t.Fatalf("could not create wave reader: %v", err)
+			}
+			format := wavr.Format
+			format.BitsPerSample = tc.bps
+			format.BlockAlign = format.NumChans * tc.bps / 8
+			format.ByteRate = format.SampleRate * uint32(format.BlockAlign)
+			ws := &writerseeker.WriterSeeker{}
+			wavw, err := wave.NewWriter(ws, format)
+			if err != nil {
+				t.Fatalf("could not create wave writer: %v", err)
+			}
+			if _, err := wave.Convert(context.Background(), wavw, wavr, nil); err != nil {
+				t.Fatalf("could not convert samples: %v", err)
+			}
+			if err := wavw.Close(); err != nil {
+				t.Fatalf("could not close wave writer: %v", err)
+			}
+			wavr, err = wave.NewReader(ws.Reader())
+			if err != nil {
+				t.Fatalf("could not create wave reader: %v", err)
+			}
+			samples, err := wavr.Samples()
+			if err != nil {
+				t.Fatalf("could not read samples: %v", err)
+			}
+			if fmt.Sprint(samples[:8]) != fmt.Sprint(tc.out) {
+				t.Fatalf("expected samples to be\n%v, got\n%v", tc.out, samples[:8])
+			}
+		})
+	}
+}
+
+func TestAnalyzeCanceled(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n, err := wave.Analyze(ctx, wavr, func([]int) error { return nil }, nil)
+	if err != context.Canceled {
+		t.Fatalf("expected context to be canceled, got %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("expected no frames to be analyzed, got %d", n)
+	}
+}
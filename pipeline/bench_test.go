@@ -0,0 +1,28 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/mix"
+	"github.com/bake/wave/pipeline"
+)
+
+func benchmarkRun(b *testing.B, concurrent bool) {
+	ws := testFile(b, 1<<16)
+	p := pipeline.New(pipeline.Mix(mix.StereoToMono(), 0), pipeline.Gain(-3))
+	b.SetBytes(1 << 16 * 6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runFile(b, ws, func(dst wave.Sink, src wave.Source) (int64, error) {
+			if concurrent {
+				return p.RunConcurrent(context.Background(), dst, src)
+			}
+			return p.Run(context.Background(), dst, src)
+		})
+	}
+}
+
+func BenchmarkRun(b *testing.B)           { benchmarkRun(b, false) }
+func BenchmarkRunConcurrent(b *testing.B) { benchmarkRun(b, true) }
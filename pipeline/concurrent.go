@@ -0,0 +1,266 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/bake/wave"
+	"github.com/pkg/errors"
+)
+
+// DefaultDepth is the number of blocks buffered between two stages of a
+// concurrent pipeline.
+const DefaultDepth = 4
+
+// rawSource is a source whose bytes can be read and decoded separately, like
+// a wave.Reader.
+type rawSource interface {
+	io.Reader
+	FrameFormat() wave.Format
+}
+
+// rawSink is a sink whose samples can be encoded and written separately, like
+// a wave.Writer.
+type rawSink interface {
+	io.Writer
+	AppendFloats(p []byte, src []float64) []byte
+}
+
+// block is a buffer passed between stages.
+type block struct {
+	raw     []byte
+	samples []float64
+}
+
+// stage runs a step of a concurrent pipeline.
+type stage func(ctx context.Context, in <-chan *block, out chan<- *block) error
+
+// RunConcurrent does the same as Run, but reads, decodes, processes, encodes
+// and writes blocks on separate goroutines, each processor on its own. Up to
+// Depth blocks are buffered between two stages and buffers are reused.
+// Processors must not keep references to the samples passed to them.
+func (p *Pipeline) RunConcurrent(ctx context.Context, dst wave.Sink, src wave.Source) (int64, error) {
+	in := src.FrameFormat()
+	if err := in.CheckFrames(); err != nil {
+		return 0, err
+	}
+	if err := check(p.Format(in), dst.FrameFormat()); err != nil {
+		return 0, err
+	}
+	blockSize := p.BlockSize
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	depth := p.Depth
+	if depth <= 0 {
+		depth = DefaultDepth
+	}
+	pool := &sync.Pool{New: func() interface{} { return &block{} }}
+	var frames int64
+	chans := int(in.NumChans)
+
+	var stages []stage
+	if raw, ok := src.(rawSource); ok && in.Decodable() {
+		size := blockSize * int(in.BlockAlign)
+		stages = append(stages, readStage(raw, pool, size), decodeStage(in, &frames))
+	} else {
+		stages = append(stages, sourceStage(src, pool, blockSize*chans, &frames))
+	}
+	for i, proc := range p.Processors {
+		stages = append(stages, processStage(i, proc, pool))
+	}
+	if raw, ok := dst.(rawSink); ok {
+		stages = append(stages, encodeStage(raw), writeStage(raw, pool))
+	} else {
+		stages = append(stages, sinkStage(dst, pool))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first error
+	var ch chan *block
+	for _, s := range stages {
+		out := make(chan *block, depth)
+		wg.Add(1)
+		go func(s stage, in <-chan *block, out chan<- *block) {
+			defer wg.Done()
+			defer close(out)
+			if err := s(ctx, in, out); err != nil {
+				once.Do(func() { first = err })
+				cancel()
+			}
+		}(s, ch, out)
+		ch = out
+	}
+	wg.Wait()
+	if first != nil {
+		return frames, first
+	}
+	return frames, ctx.Err()
+}
+
+// send passes a block to the next stage unless ctx is done.
+func send(ctx context.Context, out chan<- *block, b *block) error {
+	select {
+	case out <- b:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// receive returns the next block from the previous stage or nil after the last
+// one.
+func receive(ctx context.Context, in <-chan *block) (*block, error) {
+	select {
+	case b := <-in:
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// each calls fn with every block received from the previous stage.
+func each(ctx context.Context, in <-chan *block, fn func(b *block) error) error {
+	for {
+		b, err := receive(ctx, in)
+		if err != nil || b == nil {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+}
+
+// readStage reads raw bytes of whole frames.
+func readStage(r io.Reader, pool *sync.Pool, size int) stage {
+	return func(ctx context.Context, _ <-chan *block, out chan<- *block) error {
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			b := pool.Get().(*block)
+			if cap(b.raw) < size {
+				b.raw = make([]byte, size)
+			}
+			n, err := io.ReadFull(r, b.raw[:size])
+			b.raw = b.raw[:n]
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				if n == 0 {
+					return nil
+				}
+				return send(ctx, out, b)
+			}
+			if err != nil {
+				return errors.Wrap(err, "could not read samples")
+			}
+			if err := send(ctx, out, b); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// decodeStage decodes raw bytes into samples and counts the frames.
+func decodeStage(format wave.Format, frames *int64) stage {
+	chans := int(format.NumChans)
+	size := format.ContainerSize()
+	return func(ctx context.Context, in <-chan *block, out chan<- *block) error {
+		return each(ctx, in, func(b *block) error {
+			n := len(b.raw) / size
+			n -= n % chans
+			if cap(b.samples) < n {
+				b.samples = make([]float64, n)
+			}
+			b.samples = b.samples[:format.DecodeFloats(b.samples[:n], b.raw)]
+			*frames += int64(len(b.samples) / chans)
+			return send(ctx, out, b)
+		})
+	}
+}
+
+// sourceStage reads samples from a source.
+func sourceStage(src wave.Source, pool *sync.Pool, size int, frames *int64) stage {
+	chans := int(src.FrameFormat().NumChans)
+	return func(ctx context.Context, _ <-chan *block, out chan<- *block) error {
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			b := pool.Get().(*block)
+			if cap(b.samples) < size {
+				b.samples = make([]float64, size)
+			}
+			n, err := src.Floats(b.samples[:size])
+			if err != nil && err != io.EOF {
+				return errors.Wrap(err, "could not read samples")
+			}
+			b.samples = b.samples[:n-n%chans]
+			*frames += int64(n / chans)
+			if n > 0 {
+				if err := send(ctx, out, b); err != nil {
+					return err
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+		}
+	}
+}
+
+// processStage runs a processor. Its output is written into a second block
+// and the input block is returned to the pool.
+func processStage(i int, proc Processor, pool *sync.Pool) stage {
+	return func(ctx context.Context, in <-chan *block, out chan<- *block) error {
+		return each(ctx, in, func(b *block) error {
+			next := pool.Get().(*block)
+			var err error
+			if next.samples, err = proc.Process(next.samples[:0], b.samples); err != nil {
+				return errors.Wrapf(err, "could not process samples in stage %d", i)
+			}
+			pool.Put(b)
+			return send(ctx, out, next)
+		})
+	}
+}
+
+// encodeStage encodes samples into raw bytes.
+func encodeStage(w rawSink) stage {
+	return func(ctx context.Context, in <-chan *block, out chan<- *block) error {
+		return each(ctx, in, func(b *block) error {
+			b.raw = w.AppendFloats(b.raw[:0], b.samples)
+			return send(ctx, out, b)
+		})
+	}
+}
+
+// writeStage writes raw bytes.
+func writeStage(w io.Writer, pool *sync.Pool) stage {
+	return func(ctx context.Context, in <-chan *block, _ chan<- *block) error {
+		return each(ctx, in, func(b *block) error {
+			if _, err := w.Write(b.raw); err != nil {
+				return errors.Wrap(err, "could not write samples")
+			}
+			pool.Put(b)
+			return nil
+		})
+	}
+}
+
+// sinkStage writes samples to a sink.
+func sinkStage(dst wave.Sink, pool *sync.Pool) stage {
+	return func(ctx context.Context, in <-chan *block, _ chan<- *block) error {
+		return each(ctx, in, func(b *block) error {
+			if err := dst.Floats(b.samples); err != nil {
+				return errors.Wrap(err, "could not write samples")
+			}
+			pool.Put(b)
+			return nil
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package pipeline_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/bake/wave/mix"
+	"github.com/bake/wave/pipeline"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+// testFile returns a file of 24 bit stereo frames.
+func testFile(t testing.TB, frames int) *writerseeker.WriterSeeker {
+	samples := make([]int, 2*frames)
+	for i := range samples {
+		samples[i] = (i*7919)%(1<<23) - 1<<22
+	}
+	return wavetest.Write(t, wavetest.Format(2, 8000, 24), func(wavw *wave.Writer) error {
+		return wavw.Samples(samples)
+	})
+}
+
+// runFile passes a file through a pipeline into a 16 bit file using run and
+// returns its samples.
+func runFile(t testing.TB, ws *writerseeker.WriterSeeker, run func(wave.Sink, wave.Source) (int64, error)) []int {
+	src := wavetest.Read(t, ws)
+	out := wavetest.Write(t, format(1), func(wavw *wave.Writer) error {
+		_, err := run(wavw, src)
+		return err
+	})
+	return wavetest.ReadSamples(t, out)
+}
+
+func TestRunConcurrent(t *testing.T) {
+	ws := testFile(t, 1000)
+	p := pipeline.New(pipeline.Mix(mix.StereoToMono(), 0), pipeline.Gain(-3))
+	p.BlockSize = 64
+	p.Depth = 2
+	exp := runFile(t, ws, func(dst wave.Sink, src wave.Source) (int64, error) {
+		return p.Run(context.Background(), dst, src)
+	})
+	got := runFile(t, ws, func(dst wave.Sink, src wave.Source) (int64, error) {
+		frames, err := p.RunConcurrent(context.Background(), dst, src)
+		if frames != 1000 {
+			t.Fatalf("expected 1000 frames to be read, got %d", frames)
+		}
+		return frames, err
+	})
+	if len(got) != 1000 || fmt.Sprint(got) != fmt.Sprint(exp) {
+		t.Fatalf("expected concurrent samples to equal synchronous ones")
+	}
+}
+
+func TestRunConcurrentSlices(t *testing.T) {
+	src := &source{format(2), []float64{0.1, 0.3, -0.2, -0.4, 0.5, 0.5}}
+	dst := &sink{format: format(1)}
+	p := pipeline.New(pipeline.Mix(mix.StereoToMono(), 0), pipeline.Gain(6.0206))
+	p.BlockSize = 2
+	frames, err := p.RunConcurrent(context.Background(), dst, src)
+	if err != nil {
+		t.Fatalf("could not run pipeline: %v", err)
+	}
+	if frames != 3 {
+		t.Fatalf("expected 3 frames to be processed, got %d", frames)
+	}
+	if got, exp := fmt.Sprintf("%.3f", dst.samples), "[0.400 -0.600 1.000]"; got != exp {
+		t.Fatalf("expected samples to be %s, got %s", exp, got)
+	}
+}
+
+// failure is a processor that fails.
+type failure struct{}
+
+func (failure) Format(in wave.Format) wave.Format { return in }
+
+func (failure) Process(dst, src []float64) ([]float64, error) {
+	return dst, errors.New("failure")
+}
+
+func TestRunConcurrentError(t *testing.T) {
+	src := &source{format(1), make([]float64, 100)}
+	p := pipeline.New(pipeline.Gain(0), failure{}, pipeline.Gain(0))
+	p.BlockSize = 1
+	if _, err := p.RunConcurrent(context.Background(), &sink{format: format(1)}, src); err == nil {
+		t.Fatalf("expected the error of a processor to be returned")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	src = &source{format(1), make([]float64, 100)}
+	if _, err := p.RunConcurrent(ctx, &sink{format: format(1)}, src); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestRunConcurrentNoChannels(t *testing.T) {
+	_, err := pipeline.New().RunConcurrent(context.Background(), &sink{format: format(0)}, wavetest.NoChannels(t))
+	if !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
@@ -0,0 +1,168 @@
+// Package pipeline connects sources of samples through processors to sinks.
+package pipeline
+
+import (
+	"context"
+	"io"
+	"math"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/mix"
+	"github.com/pkg/errors"
+)
+
+// DefaultBlockSize is the number of frames passed through a pipeline at once.
+const DefaultBlockSize = wave.BlockSize
+
+// Processor transforms interleaved frames of samples.
+type Processor interface {
+	// Format returns the format of the frames produced from frames in the
+	// format in.
+	Format(in wave.Format) wave.Format
+	// Process appends the processed frames of src to dst.
+	Process(dst, src []float64) ([]float64, error)
+}
+
+// Func turns a function that processes samples in place into a Processor. It
+// can also be used to observe samples, for example by an analyzer.
+type Func func(samples []float64)
+
+// Format returns in.
+func (fn Func) Format(in wave.Format) wave.Format { return in }
+
+// Process calls the function with src and appends it to dst.
+func (fn Func) Process(dst, src []float64) ([]float64, error) {
+	fn(src)
+	return append(dst, src...), nil
+}
+
+// Gain amplifies samples by db decibels.
+func Gain(db float64) Processor {
+	factor := math.Pow(10, db/20)
+	return Func(func(samples []float64) {
+		for i := range samples {
+			samples[i] *= factor
+		}
+	})
+}
+
+// mixer applies a mix.Matrix.
+type mixer struct {
+	m    mix.Matrix
+	mask uint32
+}
+
+// Mix applies a matrix to the channels. The output has the speaker positions
+// of mask.
+func Mix(m mix.Matrix, mask uint32) Processor {
+	return mixer{m, mask}
+}
+
+func (m mixer) Format(in wave.Format) wave.Format { return m.m.Format(in, m.mask) }
+
+func (m mixer) Process(dst, src []float64) ([]float64, error) {
+	return m.m.Process(dst, src), nil
+}
+
+// Pipeline passes blocks of frames from a source through processors into a
+// sink. The memory used is bounded by the size of a block.
+type Pipeline struct {
+	BlockSize  int // Frames per block, DefaultBlockSize if 0.
+	Depth      int // Blocks buffered between stages of RunConcurrent, DefaultDepth if 0.
+	Processors []Processor
+}
+
+// New creates a pipeline of processors.
+func New(procs ...Processor) *Pipeline {
+	return &Pipeline{Processors: procs}
+}
+
+// Format returns the format of the frames produced from frames in the format
+// in.
+func (p *Pipeline) Format(in wave.Format) wave.Format {
+	for _, proc := range p.Processors {
+		in = proc.Format(in)
+	}
+	return in
+}
+
+// Process passes src through all processors and appends the result to dst.
+// This makes a pipeline a processor itself.
+func (p *Pipeline) Process(dst, src []float64) ([]float64, error) {
+	for _, proc := range p.Processors {
+		var err error
+		if src, err = proc.Process(nil, src); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, src...), nil
+}
+
+// Run reads frames from src until EOF or until ctx is done, passes them
+// through the processors and writes them to dst. It returns the number of
+// frames read.
+func (p *Pipeline) Run(ctx context.Context, dst wave.Sink, src wave.Source) (int64, error) {
+	in := src.FrameFormat()
+	if err := in.CheckFrames(); err != nil {
+		return 0, err
+	}
+	if err := check(p.Format(in), dst.FrameFormat()); err != nil {
+		return 0, err
+	}
+	blockSize := p.BlockSize
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	// Each stage reuses its own buffer.
+	bufs := make([][]float64, len(p.Processors))
+	format := in
+	for i, proc := range p.Processors {
+		format = proc.Format(format)
+		bufs[i] = make([]float64, 0, blockSize*int(format.NumChans))
+	}
+	chans := int(in.NumChans)
+	block := make([]float64, blockSize*chans)
+	var frames int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return frames, err
+		}
+		n, err := src.Floats(block)
+		if err != nil && err != io.EOF {
+			return frames, errors.Wrap(err, "could not read samples")
+		}
+		frames += int64(n / chans)
+		samples := block[:n-n%chans]
+		for i, proc := range p.Processors {
+			var err error
+			if bufs[i], err = proc.Process(bufs[i][:0], samples); err != nil {
+				return frames, errors.Wrapf(err, "could not process samples in stage %d", i)
+			}
+			samples = bufs[i]
+		}
+		if err := dst.Floats(samples); err != nil {
+			return frames, errors.Wrap(err, "could not write samples")
+		}
+		if err == io.EOF {
+			return frames, nil
+		}
+	}
+}
+
+// Run passes all frames from src through the processors into dst using the
+// default block size.
+func Run(ctx context.Context, dst wave.Sink, src wave.Source, procs ...Processor) (int64, error) {
+	return New(procs...).Run(ctx, dst, src)
+}
+
+// check returns an error if frames in format out can not be written to a sink
+// of format sink.
+func check(out, sink wave.Format) error {
+	if out.NumChans != sink.NumChans {
+		return errors.Errorf("pipeline produces %d channels, sink expects %d", out.NumChans, sink.NumChans)
+	}
+	if out.SampleRate != sink.SampleRate {
+		return errors.Errorf("pipeline produces %d Hz, sink expects %d Hz", out.SampleRate, sink.SampleRate)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package pipeline_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/bake/wave/mix"
+	"github.com/bake/wave/pipeline"
+	"github.com/orcaman/writerseeker"
+	"github.com/pkg/errors"
+)
+
+func format(chans int) wave.Format {
+	return wave.Format{
+		AudioFormat:   1,
+		NumChans:      uint16(chans),
+		SampleRate:    8000,
+		ByteRate:      uint32(8000 * chans * 2),
+		BlockAlign:    uint16(chans * 2),
+		BitsPerSample: 16,
+	}
+}
+
+// source is a wave.Source of a slice of samples.
+type source struct {
+	format  wave.Format
+	samples []float64
+}
+
+func (s *source) FrameFormat() wave.Format { return s.format }
+
+func (s *source) Floats(dst []float64) (int, error) {
+	if len(s.samples) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(dst, s.samples)
+	s.samples = s.samples[n:]
+	return n, nil
+}
+
+// sink is a wave.Sink collecting samples.
+type sink struct {
+	format  wave.Format
+	samples []float64
+}
+
+func (s *sink) FrameFormat() wave.Format { return s.format }
+
+func (s *sink) Floats(src []float64) error {
+	s.samples = append(s.samples, src...)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	src := &source{format(2), []float64{0.1, 0.3, -0.2, -0.4, 0.5, 0.5}}
+	dst := &sink{format: format(1)}
+	var observed int
+	p := pipeline.New(
+		pipeline.Mix(mix.StereoToMono(), 0),
+		pipeline.Func(func(samples []float64) { observed += len(samples) }),
+		pipeline.Gain(6.0206),
+	)
+	p.BlockSize = 2
+	frames, err := p.Run(context.Background(), dst, src)
+	if err != nil {
+		t.Fatalf("could not run pipeline: %v", err)
+	}
+	if frames != 3 || observed != 3 {
+		t.Fatalf("expected 3 frames to be processed, got %d and %d", frames, observed)
+	}
+	if got, exp := fmt.Sprintf("%.3f", dst.samples), "[0.400 -0.600 1.000]"; got != exp {
+		t.Fatalf("expected samples to be %s, got %s", exp, got)
+	}
+}
+
+func TestRunFormat(t *testing.T) {
+	src := &source{format: format(2)}
+	if _, err := pipeline.Run(context.Background(), &sink{format: format(2)}, src, pipeline.Mix(mix.StereoToMono(), 0)); err == nil {
+		t.Fatalf("expected mono frames not to be written to a stereo sink")
+	}
+}
+
+func TestRunNoChannels(t *testing.T) {
+	// The sink matches the source, so that only the missing channels fail.
+	if _, err := pipeline.Run(context.Background(), &sink{format: format(0)}, wavetest.NoChannels(t)); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format(1))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples([]int{100, -200, 300}); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	wavr, err := wave.NewReader(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	out := &writerseeker.WriterSeeker{}
+	wavw, err = wave.NewWriter(out, format(2))
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	// A pipeline is a processor itself.
+	inner := pipeline.New(pipeline.Gain(-6.0206))
+	if _, err := pipeline.Run(context.Background(), wavw, wavr, pipeline.Mix(mix.MonoToStereo(), 0), inner); err != nil {
+		t.Fatalf("could not run pipeline: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	wavr, err = wave.NewReader(out.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if got, exp := fmt.Sprint(samples), "[50 50 -100 -100 150 150]"; got != exp {
+		t.Fatalf("expected samples to be %s, got %s", exp, got)
+	}
+}
@@ -2,6 +2,8 @@ package wave
 
 import (
 	"io"
+	"io/ioutil"
+	"sort"
 
 	"github.com/bake/wave/riff"
 	"github.com/pkg/errors"
@@ -9,13 +11,63 @@ import (
 
 // Reader reads samples from a WAVE file.
 type Reader struct {
-	rr     *riff.Reader
-	Format Format
+	r       io.Reader
+	rr      *riff.Reader
+	lr      *riff.Reader // Wave list, if any.
+	base    int64        // Position of the RIFF header in a seekable r.
+	Format  Format
+	strict  bool
+	lenient bool
+	signed  bool
+	repairs []riff.Repair
+	n       int64     // Bytes read from the current chunk.
+	silence io.Reader // Samples of the current silence chunk.
+	segment Segment
+	bext    *Bext
+	err     error // Error that stopped reading, io.EOF after the last chunk.
+	limits  Limits
+	total   int64 // Bytes of samples read from all chunks.
+}
+
+// ReaderOption configures optional behaviour of a Reader.
+type ReaderOption func(*Reader)
+
+// Strict makes the reader reject files that are inconsistent, incomplete or
+// not supported instead of failing later or silently returning fewer samples.
+// Errors are returned as *ValidationError wrapping one of the Err* values.
+func Strict() ReaderOption {
+	return func(wavr *Reader) { wavr.strict = true }
+}
+
+// Lenient makes the reader recover from defects commonly found in files that
+// have not been closed properly, like recordings interrupted by a power loss.
+// Sizes of chunks that don't match the file are inferred if r is an io.Seeker,
+// missing padding bytes are tolerated and inconsistent block aligns and byte
+// rates are recalculated. See Repairs for what has been recovered.
+func Lenient() ReaderOption {
+	return func(wavr *Reader) { wavr.lenient = true }
+}
+
+// ReadSigned makes the reader return 8 bit samples as signed values between
+// -128 and 127, like samples of all other bit depths, instead of the unsigned
+// values stored in the file.
+func ReadSigned() ReaderOption {
+	return func(wavr *Reader) { wavr.signed = true }
 }
 
 // NewReader reads the initial chunks from a WAVE file and returns a new reader.
-func NewReader(r io.Reader) (*Reader, error) {
-	rr, t, err := riff.NewReader(r)
+func NewReader(r io.Reader, opts ...ReaderOption) (*Reader, error) {
+	wavr := &Reader{r: r, segment: Segment{Index: -1}}
+	for _, opt := range opts {
+		opt(wavr)
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		var err error
+		if wavr.base, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, errors.Wrap(err, "could not get current position")
+		}
+	}
+	rr, t, err := riff.NewReader(r, wavr.riffOptions()...)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create new riff reader")
 	}
@@ -28,37 +80,325 @@ func NewReader(r io.Reader) (*Reader, error) {
 		}
 		return nil, errors.Wrap(rr.Error(), "could not read format chunk")
 	}
-	id, _, data := rr.Chunk()
+	id, size, data := rr.Chunk()
 	if id != "fmt " {
 		return nil, errors.Errorf("unexpected chunk id %s", id)
 	}
 	format, err := decodeFormat(data)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = &ValidationError{rr.Offset(), id, errors.Wrapf(ErrTruncatedChunk, "%d bytes", size)}
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "could not decode format chunk")
 	}
-	return &Reader{rr, format}, nil
+	// Extended format chunks are longer than the fields decoded above.
+	if _, err := io.Copy(ioutil.Discard, data); err != nil {
+		return nil, errors.Wrap(err, "could not skip rest of format chunk")
+	}
+	wavr.rr = rr
+	wavr.Format = format
+	if wavr.lenient {
+		wavr.repairs = wavr.Format.repair(rr.Offset())
+	}
+	if !wavr.strict {
+		return wavr, nil
+	}
+	if err := format.Validate(); err != nil {
+		return nil, &ValidationError{rr.Offset(), id, err}
+	}
+	if err := wavr.seekData(); err != nil {
+		return nil, err
+	}
+	return wavr, nil
+}
+
+// Rewind starts reading from the first chunk again. This requires the
+// underlying reader to be an io.Seeker.
+func (wavr *Reader) Rewind() error {
+	seeker, ok := wavr.r.(io.Seeker)
+	if !ok {
+		return ErrNotSeekable
+	}
+	if _, err := seeker.Seek(wavr.base, io.SeekStart); err != nil {
+		return errors.Wrap(err, "could not seek to beginning of file")
+	}
+	var opts []ReaderOption
+	if wavr.strict {
+		opts = append(opts, Strict())
+	}
+	if wavr.lenient {
+		opts = append(opts, Lenient())
+	}
+	if wavr.signed {
+		opts = append(opts, ReadSigned())
+	}
+	opts = append(opts, Limit(wavr.limits))
+	r, err := NewReader(wavr.r, opts...)
+	if err != nil {
+		return err
+	}
+	*wavr = *r
+	return nil
+}
+
+// riffOptions returns the options for the underlying RIFF reader.
+func (wavr *Reader) riffOptions() []riff.Option {
+	var opts []riff.Option
+	if wavr.lenient {
+		opts = append(opts, riff.Lenient())
+	}
+	if wavr.limits.MaxChunks > 0 {
+		opts = append(opts, riff.MaxChunks(wavr.limits.MaxChunks))
+	}
+	return opts
+}
+
+// scan calls fn with a new RIFF reader starting at the first chunk. This
+// requires the underlying reader to be an io.Seeker. Its position is restored
+// afterwards, even if fn fails.
+func (wavr *Reader) scan(fn func(rr *riff.Reader) error) (err error) {
+	seeker, ok := wavr.r.(io.Seeker)
+	if !ok {
+		return ErrNotSeekable
+	}
+	pos, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "could not get current position")
+	}
+	defer func() {
+		if _, serr := seeker.Seek(pos, io.SeekStart); serr != nil && err == nil {
+			err = errors.Wrap(serr, "could not restore position")
+		}
+	}()
+	if _, err := seeker.Seek(wavr.base, io.SeekStart); err != nil {
+		return errors.Wrap(err, "could not seek to beginning of file")
+	}
+	rr, _, err := riff.NewReader(wavr.r, wavr.riffOptions()...)
+	if err != nil {
+		return errors.Wrap(err, "could not create new riff reader")
+	}
+	if err := fn(rr); err != nil {
+		return err
+	}
+	return errors.Wrap(rr.Error(), "could not read chunk")
+}
+
+// Repairs returns the defects that have been recovered from so far in lenient
+// mode, ordered by their offset.
+func (wavr *Reader) Repairs() []riff.Repair {
+	repairs := append(append([]riff.Repair{}, wavr.rr.Repairs()...), wavr.repairs...)
+	sort.SliceStable(repairs, func(i, j int) bool { return repairs[i].Offset < repairs[j].Offset })
+	return repairs
+}
+
+// seekData skips chunks until the first chunk containing samples.
+func (wavr *Reader) seekData() error {
+	_, size, _ := wavr.rr.Chunk()
+	end := wavr.rr.Offset() + 8 + size
+	for {
+		wavr.err = wavr.next()
+		if wavr.err == io.EOF {
+			return &ValidationError{Offset: end, Err: ErrMissingDataChunk}
+		}
+		if wavr.err != nil {
+			return wavr.err
+		}
+		if wavr.payload() != nil {
+			return nil
+		}
+		if wavr.err = wavr.skip(); wavr.err != nil {
+			return wavr.err
+		}
+		_, size, _ := wavr.chunks().Chunk()
+		end = wavr.chunks().Offset() + 8 + size
+	}
 }
 
 // Sample returns the next sample from the wave file. Chunks that don't contain
-// samples are skipped.
+// samples are skipped. 8 bit samples are unsigned unless the reader has been
+// created with ReadSigned.
 func (wavr *Reader) Sample() (int, error) {
-	id, size, data := wavr.rr.Chunk()
-	if id != "data" {
-		body := make([]byte, size)
-		if _, err := data.Read(body); err != nil && err != io.EOF {
-			return 0, errors.Wrapf(err, "could not skip %s chunk", id)
+	s, err := wavr.rawSample()
+	return s - wavr.offset(), err
+}
+
+// offset returns the value that is subtracted from samples as stored in the
+// file to convert them to the convention of the reader.
+func (wavr *Reader) offset() int {
+	if wavr.signed && wavr.Format.BitsPerSample == 8 {
+		return 128
+	}
+	return 0
+}
+
+// rawSample returns the next sample as stored in the file.
+func (wavr *Reader) rawSample() (int, error) {
+	for {
+		if r := wavr.payload(); r != nil {
+			s, err := wavr.sample(r)
+			if err != io.EOF {
+				return s, err
+			}
+		}
+		if err := wavr.advance(); err != nil {
+			return 0, err
 		}
-		wavr.rr.Next()
-		return wavr.Sample()
 	}
-	s, err := wavr.sample(data)
-	if err == io.EOF {
+}
+
+// chunks returns the reader of the current list or the RIFF reader.
+func (wavr *Reader) chunks() *riff.Reader {
+	if wavr.lr != nil {
+		return wavr.lr
+	}
+	return wavr.rr
+}
+
+// payload returns the samples of the current chunk or nil if it does not
+// contain any.
+func (wavr *Reader) payload() io.Reader {
+	if id, _, data := wavr.chunks().Chunk(); id == "data" {
+		return data
+	}
+	return wavr.silence
+}
+
+// next advances to the next chunk and descends into wave lists. It returns
+// io.EOF after the last chunk.
+func (wavr *Reader) next() error {
+	wavr.n = 0
+	wavr.silence = nil
+	for {
+		if wavr.lr != nil {
+			if wavr.lr.Next() {
+				return wavr.enter()
+			}
+			if err := wavr.lr.Error(); err != nil {
+				return errors.Wrap(err, "could not read wave list")
+			}
+			wavr.lr = nil
+		}
 		if !wavr.rr.Next() {
-			return 0, io.EOF
+			return wavr.eof()
+		}
+		if id, _, _ := wavr.rr.Chunk(); id != "LIST" {
+			return wavr.enter()
+		}
+		lr, t, err := wavr.rr.List()
+		if err != nil || t != "wavl" {
+			// Other lists are skipped like any other chunk.
+			return nil
+		}
+		wavr.lr = lr
+	}
+}
+
+// enter starts reading the current chunk.
+func (wavr *Reader) enter() error {
+	if id, _, _ := wavr.chunks().Chunk(); id == "bext" && wavr.lr == nil {
+		return wavr.readBext()
+	}
+	segment, ok, err := wavr.chunkSegment(wavr.segment, wavr.chunks(), wavr.lr != nil)
+	if err != nil || !ok {
+		return err
+	}
+	wavr.segment = segment
+	if segment.Silent {
+		wavr.n = 4
+		wavr.silence = io.LimitReader(newSilence(wavr.Format), segment.Frames()*int64(wavr.Format.BlockAlign))
+	}
+	return nil
+}
+
+// advance finishes the current chunk and moves on to the next one. It returns
+// io.EOF after the last chunk. Once it failed, it keeps returning the error.
+func (wavr *Reader) advance() error {
+	if wavr.err != nil {
+		return wavr.err
+	}
+	if id, _, _ := wavr.chunks().Chunk(); id == "data" {
+		wavr.err = wavr.checkSize()
+	} else {
+		wavr.err = wavr.skip()
+	}
+	if wavr.err == nil {
+		wavr.err = wavr.next()
+	}
+	return wavr.err
+}
+
+// Read reads the raw bytes of all data chunks. Other chunks are skipped and
+// silence chunks in wave lists are expanded.
+func (wavr *Reader) Read(p []byte) (int, error) {
+	for {
+		if r := wavr.payload(); r != nil {
+			n, err := r.Read(p)
+			if err := wavr.consume(int64(n)); err != nil {
+				return n, err
+			}
+			if n > 0 || err != io.EOF {
+				return n, err
+			}
+		}
+		if err := wavr.advance(); err != nil {
+			return 0, err
 		}
-		return wavr.Sample()
 	}
-	return s, err
+}
+
+// WriteTo writes the raw bytes of all data chunks to w. Other chunks are
+// skipped and silence chunks in wave lists are expanded.
+func (wavr *Reader) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for {
+		if r := wavr.payload(); r != nil {
+			if max := wavr.maxBytes(); max >= 0 {
+				// Copy at most one byte more than allowed.
+				r = io.LimitReader(r, max-wavr.total+1)
+			}
+			n, err := io.Copy(w, r)
+			total += n
+			if err := wavr.consume(n); err != nil {
+				return total, err
+			}
+			if err != nil {
+				return total, err
+			}
+		}
+		if err := wavr.advance(); err == io.EOF {
+			return total, nil
+		} else if err != nil {
+			return total, err
+		}
+	}
+}
+
+// skip discards the rest of the current chunk.
+func (wavr *Reader) skip() error {
+	id, _, _ := wavr.chunks().Chunk()
+	if err := wavr.chunks().Skip(); err != nil {
+		return errors.Wrapf(err, "could not skip %s chunk", id)
+	}
+	return nil
+}
+
+// checkSize returns an error in strict mode if the current chunk ended before
+// its declared size.
+func (wavr *Reader) checkSize() error {
+	id, size, _ := wavr.chunks().Chunk()
+	if !wavr.strict || wavr.n >= size {
+		return nil
+	}
+	return &ValidationError{wavr.chunks().Offset(), id,
+		errors.Wrapf(ErrTruncatedChunk, "expected %d bytes, got %d", size, wavr.n)}
+}
+
+// eof returns the error of the underlying RIFF reader or io.EOF.
+func (wavr *Reader) eof() error {
+	if err := wavr.rr.Error(); err != nil {
+		return errors.Wrap(err, "could not read chunk")
+	}
+	return io.EOF
 }
 
 // Samples reads the whole file and returns all samples.
@@ -77,21 +417,43 @@ func (wavr *Reader) Samples() ([]int, error) {
 	return samples, nil
 }
 
+// frame reads one sample per channel. It returns io.EOF if there are no more
+// complete frames.
+func (wavr *Reader) frame(frame []int) error {
+	for i := range frame {
+		s, err := wavr.Sample()
+		if err == io.EOF && i > 0 && wavr.strict {
+			return &ValidationError{wavr.chunks().Offset(), "data",
+				errors.Wrapf(ErrTruncatedChunk, "incomplete frame of %d samples", i)}
+		}
+		if err != nil {
+			return err
+		}
+		frame[i] = s
+	}
+	return nil
+}
+
 func (wavr *Reader) sample(r io.Reader) (int, error) {
-	s := make([]byte, wavr.Format.BitsPerSample/8)
-	if _, err := r.Read(s); err != nil {
+	if !wavr.Format.Decodable() {
+		return 0, errors.Wrapf(ErrUnsupportedFormat, "%d bits per sample in %d bytes",
+			wavr.Format.BitsPerSample, wavr.Format.ContainerSize())
+	}
+	s := make([]byte, wavr.Format.ContainerSize())
+	n, err := io.ReadFull(r, s)
+	if err := wavr.consume(int64(n)); err != nil {
 		return 0, err
 	}
-	switch wavr.Format.BitsPerSample {
-	case 8:
-		return int(uint8(s[0])), nil
-	case 16:
-		return int(int16(s[0]) | int16(s[1])<<8), nil
-	case 24:
-		return int(int32(s[0]) | int32(s[1])<<8 | int32(s[2])<<16), nil
-	case 32:
-		return int(int32(s[0]) | int32(s[1])<<8 | int32(s[2])<<16 | int32(s[3])<<24), nil
-	default:
-		return 0, errors.Errorf("unpexpected bps: %d", wavr.Format.BitsPerSample)
+	if err == io.ErrUnexpectedEOF {
+		// A partial sample at the end of a chunk.
+		if wavr.strict {
+			return 0, &ValidationError{wavr.chunks().Offset(), "data",
+				errors.Wrapf(ErrTruncatedChunk, "incomplete sample of %d bytes", n)}
+		}
+		return 0, io.EOF
+	}
+	if err != nil {
+		return 0, err
 	}
+	return wavr.Format.decodeSample(s), nil
 }
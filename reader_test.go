@@ -3,14 +3,20 @@ package wave_test
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"math/rand"
 	"testing"
+	"testing/iotest"
 
 	"github.com/bake/wave"
+	"github.com/bake/wave/riff"
+	"github.com/pkg/errors"
 )
 
-func TestReader(t *testing.T) {
-	r := bytes.NewReader([]byte{
+func exampleInt16Wave() []byte {
+	return []byte{
 		// R,    I,    F,    F,                   2084,    W,    A,    V,    E,
 		0x52, 0x49, 0x46, 0x46, 0x24, 0x08, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
 
@@ -33,7 +39,11 @@ func TestReader(t *testing.T) {
 		0x64, 0x61, 0x74, 0x61, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 		//    5924,      -3298,       4924,       5180,      -1770,      -1768,
 		0x24, 0x17, 0x1e, 0xf3, 0x3c, 0x13, 0x3c, 0x14, 0x16, 0xf9, 0x18, 0xf9,
-	})
+	}
+}
+
+func TestReader(t *testing.T) {
+	r := bytes.NewReader(exampleInt16Wave())
 	out := []int{
 		0, 0, 5924, -3298, 4924, 5180, -1770, -1768,
 		-6348, -23005, -3524, -3548, -12783, 3354,
@@ -52,6 +62,122 @@ func TestReader(t *testing.T) {
 	}
 }
 
+// randomReader returns at most a random number of bytes on each read.
+type randomReader struct {
+	r   io.Reader
+	rnd *rand.Rand
+}
+
+func (r *randomReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return r.r.Read(p)
+	}
+	return r.r.Read(p[:1+r.rnd.Intn(len(p))])
+}
+
+func TestReaderShortReads(t *testing.T) {
+	readers := map[string]func(io.Reader) io.Reader{
+		"one byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+		"data err": iotest.DataErrReader,
+		"random": func(r io.Reader) io.Reader {
+			return &randomReader{r, rand.New(rand.NewSource(1))}
+		},
+	}
+	out := []int{
+		0, 0, 5924, -3298, 4924, 5180, -1770, -1768,
+		-6348, -23005, -3524, -3548, -12783, 3354,
+		0, 0, 5924, -3298, 4924, 5180, -1770, -1768,
+	}
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			r := newReader(bytes.NewReader(exampleInt16Wave()))
+			wavr, err := wave.NewReader(r, wave.Strict())
+			if err != nil {
+				t.Fatalf("could not create new wave reader: %v", err)
+			}
+			samples, err := wavr.Samples()
+			if err != nil {
+				t.Fatalf("could not read samples: %v", err)
+			}
+			if fmt.Sprint(samples) != fmt.Sprint(out) {
+				t.Fatalf("expected samples to be\n%v, got\n%v", out, samples)
+			}
+		})
+	}
+}
+
+func TestReaderRead(t *testing.T) {
+	out := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x24, 0x17, 0x1e, 0xf3, 0x3c, 0x13, 0x3c, 0x14,
+		0x16, 0xf9, 0x18, 0xf9, 0x34, 0xe7, 0x23, 0xa6, 0x3c, 0xf2, 0x24, 0xf2,
+		0x11, 0xce, 0x1a, 0x0d, 0x00, 0x00, 0x00, 0x00, 0x24, 0x17, 0x1e, 0xf3,
+		0x3c, 0x13, 0x3c, 0x14, 0x16, 0xf9, 0x18, 0xf9,
+	}
+	tt := map[string]func(*wave.Reader) ([]byte, error){
+		"read": func(wavr *wave.Reader) ([]byte, error) {
+			return ioutil.ReadAll(iotest.OneByteReader(wavr))
+		},
+		"write to": func(wavr *wave.Reader) ([]byte, error) {
+			var buf bytes.Buffer
+			_, err := wavr.WriteTo(&buf)
+			return buf.Bytes(), err
+		},
+		"sample and read": func(wavr *wave.Reader) ([]byte, error) {
+			if _, err := wavr.Sample(); err != nil {
+				return nil, err
+			}
+			body, err := ioutil.ReadAll(wavr)
+			return append([]byte{0x00, 0x00}, body...), err
+		},
+	}
+	for name, read := range tt {
+		t.Run(name, func(t *testing.T) {
+			wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+			if err != nil {
+				t.Fatalf("could not create new wave reader: %v", err)
+			}
+			body, err := read(wavr)
+			if err != nil {
+				t.Fatalf("could not read data: %v", err)
+			}
+			if fmt.Sprintf("% x", body) != fmt.Sprintf("% x", out) {
+				t.Fatalf("expected body to be\n% x, got\n% x\n", out, body)
+			}
+		})
+	}
+}
+
+func TestReaderPadding(t *testing.T) {
+	r := bytes.NewReader([]byte{
+		// R,    I,    F,    F,                     52,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x34, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+
+		// f,    m,    t,    ␣,                     16,          1,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		//               22050,                  22050,          1,          8,
+		0x22, 0x56, 0x00, 0x00, 0x22, 0x56, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00,
+
+		// n,    o,    t,    e,                      3,    a,    b,    c,  pad,
+		0x6e, 0x6f, 0x74, 0x65, 0x03, 0x00, 0x00, 0x00, 0x61, 0x62, 0x63, 0x00,
+
+		// d,    a,    t,    a,                      3,  128,  255,    0,  pad,
+		0x64, 0x61, 0x74, 0x61, 0x03, 0x00, 0x00, 0x00, 0x80, 0xff, 0x00, 0x00,
+	})
+	out := []int{128, 255, 0}
+	wavr, err := wave.NewReader(r, wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create new wave reader: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if fmt.Sprint(samples) != fmt.Sprint(out) {
+		t.Fatalf("expected samples to be\n%v, got\n%v", out, samples)
+	}
+}
+
 func TestNewReader(t *testing.T) {
 	tt := []struct {
 		name string
@@ -111,6 +237,191 @@ func TestNewReader(t *testing.T) {
 	}
 }
 
+func TestStrictReader(t *testing.T) {
+	tt := []struct {
+		name   string
+		err    error
+		offset int64
+		data   []byte
+	}{
+		{
+			name:   "inconsistent block align",
+			err:    wave.ErrInconsistentBlockAlign,
+			offset: 12,
+			data: []byte{
+				// R,    I,    F,    F,                     36,    W,    A,    V,    E,
+				0x52, 0x49, 0x46, 0x46, 0x24, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+				// f,    m,    t,    ␣,                     16,          1,          2,
+				0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00,
+				//               22050,                  44100,          2,         16,
+				0x22, 0x56, 0x00, 0x00, 0x44, 0xac, 0x00, 0x00, 0x02, 0x00, 0x10, 0x00,
+				// d,    a,    t,    a,                      0,
+				0x64, 0x61, 0x74, 0x61, 0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			name:   "unsupported bits per sample",
+			err:    wave.ErrUnsupportedFormat,
+			offset: 12,
+			data: []byte{
+				// R,    I,    F,    F,                     36,    W,    A,    V,    E,
+				0x52, 0x49, 0x46, 0x46, 0x24, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+				// f,    m,    t,    ␣,                     16,          1,          1,
+				0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+				//               22050,                      0,          0,         40,
+				0x22, 0x56, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x28, 0x00,
+				// d,    a,    t,    a,                      0,
+				0x64, 0x61, 0x74, 0x61, 0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			name:   "truncated format chunk",
+			err:    wave.ErrTruncatedChunk,
+			offset: 12,
+			data: []byte{
+				// R,    I,    F,    F,                     20,    W,    A,    V,    E,
+				0x52, 0x49, 0x46, 0x46, 0x14, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+				// f,    m,    t,    ␣,                     16,          1,          2,
+				0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00,
+			},
+		},
+		{
+			name:   "missing data chunk",
+			err:    wave.ErrMissingDataChunk,
+			offset: 48,
+			data: []byte{
+				// R,    I,    F,    F,                     40,    W,    A,    V,    E,
+				0x52, 0x49, 0x46, 0x46, 0x28, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+				// f,    m,    t,    ␣,                     16,          1,          2,
+				0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00,
+				//               22050,                  88200,          4,         16,
+				0x22, 0x56, 0x00, 0x00, 0x88, 0x58, 0x01, 0x00, 0x04, 0x00, 0x10, 0x00,
+				// s,    l,    n,    t,                      4,
+				0x73, 0x6c, 0x6e, 0x74, 0x04, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+			},
+		},
+		{
+			name:   "truncated data chunk",
+			err:    wave.ErrTruncatedChunk,
+			offset: 36,
+			data: []byte{
+				// R,    I,    F,    F,                     44,    W,    A,    V,    E,
+				0x52, 0x49, 0x46, 0x46, 0x2c, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+				// f,    m,    t,    ␣,                     16,          1,          2,
+				0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00,
+				//               22050,                  88200,          4,         16,
+				0x22, 0x56, 0x00, 0x00, 0x88, 0x58, 0x01, 0x00, 0x04, 0x00, 0x10, 0x00,
+				// d,    a,    t,    a,                     16,       5924,      -3298,
+				0x64, 0x61, 0x74, 0x61, 0x10, 0x00, 0x00, 0x00, 0x24, 0x17, 0x1e, 0xf3,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			wavr, err := wave.NewReader(bytes.NewReader(tc.data), wave.Strict())
+			if err == nil {
+				_, err = wavr.Samples()
+			}
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error to be %v, got %v", tc.err, err)
+			}
+			var verr *wave.ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected a validation error, got %T", err)
+			}
+			if verr.Offset != tc.offset {
+				t.Fatalf("expected offset to be %d, got %d", tc.offset, verr.Offset)
+			}
+		})
+	}
+}
+
+func TestLenientReader(t *testing.T) {
+	// A recording that has been interrupted before its sizes were written.
+	data := []byte{
+		// R,    I,    F,    F,                      0,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x00, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+		// f,    m,    t,    ␣,                     16,          1,          2,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00,
+		//               22050,                      0,          0,         16,
+		0x22, 0x56, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00,
+		// d,    a,    t,    a,                      0,
+		0x64, 0x61, 0x74, 0x61, 0x00, 0x00, 0x00, 0x00,
+		//    5924,      -3298,       4924,       5180,      -1770,      -1768,
+		0x24, 0x17, 0x1e, 0xf3, 0x3c, 0x13, 0x3c, 0x14, 0x16, 0xf9, 0x18, 0xf9,
+	}
+	out := []int{5924, -3298, 4924, 5180, -1770, -1768}
+	repairs := []riff.Repair{
+		{Offset: 0, ID: "RIFF", Declared: 0, Actual: 48, Reason: "RIFF size does not match file size"},
+		{Offset: 12, ID: "fmt ", Declared: 0, Actual: 4, Reason: "inconsistent block align"},
+		{Offset: 12, ID: "fmt ", Declared: 0, Actual: 88200, Reason: "inconsistent byte rate"},
+		{Offset: 36, ID: "data", Declared: 0, Actual: 12, Reason: "chunk is not followed by another chunk"},
+	}
+
+	if _, err := wave.NewReader(bytes.NewReader(data)); err == nil {
+		t.Fatal("expected an error without lenient mode")
+	}
+
+	wavr, err := wave.NewReader(bytes.NewReader(data), wave.Lenient())
+	if err != nil {
+		t.Fatalf("could not create new wave reader: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if fmt.Sprint(samples) != fmt.Sprint(out) {
+		t.Fatalf("expected samples to be\n%v, got\n%v", out, samples)
+	}
+	if fmt.Sprint(wavr.Repairs()) != fmt.Sprint(repairs) {
+		t.Fatalf("expected repairs to be\n%v, got\n%v", repairs, wavr.Repairs())
+	}
+}
+
+func TestStrictReaderEOF(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()), wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if _, err := wavr.Samples(); err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := wavr.Sample(); err != io.EOF {
+			t.Fatalf("expected EOF after the last sample, got %v", err)
+		}
+	}
+}
+
+func TestReaderRewind(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()), wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	first, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if err := wavr.Rewind(); err != nil {
+		t.Fatalf("could not rewind: %v", err)
+	}
+	second, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("expected samples to be\n%v, got\n%v", first, second)
+	}
+
+	wavr, err = wave.NewReader(struct{ io.Reader }{bytes.NewReader(exampleInt16Wave())})
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if err := wavr.Rewind(); err != wave.ErrNotSeekable {
+		t.Fatalf("expected ErrNotSeekable, got %v", err)
+	}
+}
+
 func ExampleReader() {
 	r := bytes.NewReader([]byte{
 		// R,    I,    F,    F,                     76,    W,    A,    V,    E,
@@ -0,0 +1,146 @@
+package wave
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/bake/wave/riff"
+	"github.com/pkg/errors"
+)
+
+// Repair rewrites the headers of a damaged WAVE file in place, so it can be
+// read by any other program. The file is read in lenient mode, sizes and
+// format fields are corrected where possible and a missing padding byte at the
+// end of the file is appended. It returns what has been repaired.
+//
+// Missing padding bytes between chunks can't be restored without rewriting
+// the whole file and are only reported.
+func Repair(rws io.ReadWriteSeeker) ([]riff.Repair, error) {
+	start, err := rws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get current position")
+	}
+	rr, t, err := riff.NewReader(rws, riff.Lenient())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create new riff reader")
+	}
+	if t != "WAVE" {
+		return nil, errors.Errorf("unexpected riff type %s", t)
+	}
+	var repairs []riff.Repair
+	var last struct {
+		id           string
+		offset, size int64
+	}
+	for rr.Next() {
+		id, size, data := rr.Chunk()
+		if id == "fmt " {
+			format, err := decodeFormat(data)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not decode format chunk")
+			}
+			if fixes := format.repair(rr.Offset()); len(fixes) > 0 {
+				repairs = append(repairs, fixes...)
+				// Only the byte rate and block align are repaired.
+				fields := struct {
+					ByteRate   uint32
+					BlockAlign uint16
+				}{format.ByteRate, format.BlockAlign}
+				if err := writeAt(rws, start+rr.Offset()+8+8, fields); err != nil {
+					return nil, errors.Wrap(err, "could not write format chunk")
+				}
+			}
+		}
+		if _, err := io.Copy(ioutil.Discard, data); err != nil {
+			return nil, errors.Wrapf(err, "could not skip %s chunk", id)
+		}
+		last.id, last.offset, last.size = id, rr.Offset(), size
+	}
+	if err := rr.Error(); err != nil {
+		return nil, errors.Wrap(err, "could not read chunk")
+	}
+
+	riffSize := int64(-1)
+	for _, r := range rr.Repairs() {
+		if r.ID == "RIFF" && r.Offset == 0 {
+			riffSize = r.Declared
+			continue
+		}
+		repairs = append(repairs, r)
+		if r.ID == "" || r.Declared == r.Actual {
+			continue
+		}
+		if err := writeAt(rws, start+r.Offset+4, uint32(r.Actual)); err != nil {
+			return nil, errors.Wrapf(err, "could not write size of %s chunk", r.ID)
+		}
+	}
+
+	end, err := rws.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not seek to end of file")
+	}
+	size := end - start
+	if last.size%2 == 1 && last.offset+8+last.size == size {
+		if _, err := rws.Write([]byte{0x00}); err != nil {
+			return nil, errors.Wrap(err, "could not write padding byte")
+		}
+		size++
+		repairs = append(repairs, riff.Repair{
+			Offset:   last.offset,
+			ID:       last.id,
+			Declared: last.size,
+			Actual:   last.size,
+			Reason:   "added missing padding byte",
+		})
+	}
+	if riffSize < 0 {
+		riffSize, err = readUint32At(rws, start+4)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read RIFF size")
+		}
+	}
+	if riffSize != size-8 {
+		if err := writeAt(rws, start+4, uint32(size-8)); err != nil {
+			return nil, errors.Wrap(err, "could not write RIFF size")
+		}
+		repairs = append(repairs, riff.Repair{
+			ID:       "RIFF",
+			Declared: riffSize,
+			Actual:   size - 8,
+			Reason:   "RIFF size does not match file size",
+		})
+	}
+	sort.SliceStable(repairs, func(i, j int) bool { return repairs[i].Offset < repairs[j].Offset })
+	if _, err := rws.Seek(start, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "could not seek to beginning of file")
+	}
+	return repairs, nil
+}
+
+// writeAt encodes data at offset and restores the current position.
+func writeAt(ws io.WriteSeeker, offset int64, data interface{}) error {
+	pos, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := ws.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if err := binary.Write(ws, binary.LittleEndian, data); err != nil {
+		return err
+	}
+	_, err = ws.Seek(pos, io.SeekStart)
+	return err
+}
+
+// readUint32At decodes an uint32 at offset.
+func readUint32At(rs io.ReadSeeker, offset int64) (int64, error) {
+	if _, err := rs.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	var v uint32
+	err := binary.Read(rs, binary.LittleEndian, &v)
+	return int64(v), err
+}
@@ -0,0 +1,71 @@
+package wave_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/riff"
+)
+
+func TestRepair(t *testing.T) {
+	data := []byte{
+		// R,    I,    F,    F,                      0,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x00, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+		// f,    m,    t,    ␣,                     16,          1,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		//               22050,                      0,          0,          8,
+		0x22, 0x56, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00,
+		// d,    a,    t,    a,                      0,
+		0x64, 0x61, 0x74, 0x61, 0x00, 0x00, 0x00, 0x00,
+		0x80, 0x81, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88,
+	}
+	out := []byte{
+		// R,    I,    F,    F,                     46,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x2e, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+		// f,    m,    t,    ␣,                     16,          1,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		//               22050,                  22050,          1,          8,
+		0x22, 0x56, 0x00, 0x00, 0x22, 0x56, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00,
+		// d,    a,    t,    a,                      9,
+		0x64, 0x61, 0x74, 0x61, 0x09, 0x00, 0x00, 0x00,
+		0x80, 0x81, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x00,
+	}
+	repairs := []riff.Repair{
+		{Offset: 0, ID: "RIFF", Declared: 0, Actual: 46, Reason: "RIFF size does not match file size"},
+		{Offset: 12, ID: "fmt ", Declared: 0, Actual: 1, Reason: "inconsistent block align"},
+		{Offset: 12, ID: "fmt ", Declared: 0, Actual: 22050, Reason: "inconsistent byte rate"},
+		{Offset: 36, ID: "data", Declared: 0, Actual: 9, Reason: "chunk is not followed by another chunk"},
+		{Offset: 36, ID: "data", Declared: 9, Actual: 9, Reason: "added missing padding byte"},
+	}
+
+	f, err := ioutil.TempFile("", "wave")
+	if err != nil {
+		t.Fatalf("could not create temporary file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		t.Fatalf("could not write temporary file: %v", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatalf("could not seek temporary file: %v", err)
+	}
+
+	res, err := wave.Repair(f)
+	if err != nil {
+		t.Fatalf("could not repair file: %v", err)
+	}
+	if fmt.Sprint(res) != fmt.Sprint(repairs) {
+		t.Fatalf("expected repairs to be\n%v, got\n%v", repairs, res)
+	}
+	body, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatalf("could not read temporary file: %v", err)
+	}
+	if fmt.Sprintf("% x", body) != fmt.Sprintf("% x", out) {
+		t.Fatalf("expected body to be\n% x, got\n% x\n", out, body)
+	}
+}
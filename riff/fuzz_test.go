@@ -0,0 +1,83 @@
+package riff_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/bake/wave/riff"
+)
+
+// readAll reads all chunks and lists of rr and fails if there are more chunks
+// than could fit into size bytes, which would mean that the reader loops.
+func readAll(t *testing.T, rr *riff.Reader, size int, depth int) {
+	for n := 0; rr.Next(); n++ {
+		if n > size/8 {
+			t.Fatalf("read %d chunks from %d bytes", n, size)
+		}
+		id, _, data := rr.Chunk()
+		if id == "LIST" && depth < 4 {
+			if lr, _, err := rr.List(); err == nil {
+				readAll(t, lr, size, depth+1)
+			}
+		}
+		if _, err := io.Copy(ioutil.Discard, data); err != nil {
+			return
+		}
+	}
+	rr.Error()
+	rr.Repairs()
+}
+
+// readers returns the ways a fuzzed input is read.
+func readers(data []byte) map[string]func() (io.Reader, []riff.Option) {
+	return map[string]func() (io.Reader, []riff.Option){
+		"bytes":   func() (io.Reader, []riff.Option) { return bytes.NewReader(data), nil },
+		"stream":  func() (io.Reader, []riff.Option) { return iotest.HalfReader(bytes.NewBuffer(data)), nil },
+		"lenient": func() (io.Reader, []riff.Option) { return bytes.NewReader(data), []riff.Option{riff.Lenient()} },
+		"lenient stream": func() (io.Reader, []riff.Option) {
+			return iotest.OneByteReader(bytes.NewBuffer(data)), []riff.Option{riff.Lenient()}
+		},
+	}
+}
+
+func FuzzNewReader(f *testing.F) {
+	body, _ := ioutil.ReadAll(exampleInt16WaveReader())
+	f.Add(body)
+	f.Add(body[:len(body)-3])
+	f.Add([]byte("RIFF\x00\x00\x00\x00WAVE"))
+	f.Add([]byte("RIFF\x04\x00\x00\x00TESTdat1\x01\x00\x00\x00\xff"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, newReader := range readers(data) {
+			r, opts := newReader()
+			rr, _, err := riff.NewReader(r, opts...)
+			if err != nil {
+				continue
+			}
+			readAll(t, rr, len(data), 0)
+		}
+	})
+}
+
+func FuzzNext(f *testing.F) {
+	f.Add([]byte("dat1\x03\x00\x00\x00\x01\x02\x03\x00dat2\x01\x00\x00\x00\xff"))
+	f.Add([]byte("LIST\x10\x00\x00\x00wavldata\x04\x00\x00\x00\x01\x02\x03\x04"))
+	f.Add([]byte("slnt\x04\x00\x00\x00\xff\xff\xff\xffdata\xff\xff\xff\xff"))
+	f.Add([]byte("dat1\x00\x00\x00\x00dat2\x00"))
+	f.Fuzz(func(t *testing.T, chunks []byte) {
+		// A RIFF header of the right size followed by fuzzed chunks.
+		data := append([]byte("RIFF\x00\x00\x00\x00WAVE"), chunks...)
+		size := uint32(len(data) - 8)
+		data[4], data[5], data[6], data[7] = byte(size), byte(size>>8), byte(size>>16), byte(size>>24)
+		for _, newReader := range readers(data) {
+			r, opts := newReader()
+			rr, _, err := riff.NewReader(r, opts...)
+			if err != nil {
+				t.Fatalf("could not create riff reader: %v", err)
+			}
+			readAll(t, rr, len(data), 0)
+		}
+	})
+}
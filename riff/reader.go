@@ -3,69 +3,304 @@ package riff
 import (
 	"encoding/binary"
 	"io"
+	"io/ioutil"
 
 	"github.com/pkg/errors"
 )
 
-const riffID = "RIFF"
+const (
+	riffID = "RIFF"
+	listID = "LIST"
+)
+
+// ErrTooManyChunks is returned if a file contains more chunks than allowed by
+// MaxChunks.
+var ErrTooManyChunks = errors.New("too many chunks")
 
 // Reader reads a RIFF file chunk by chunk.
 type Reader struct {
-	r     io.Reader
-	chunk struct {
-		id   string
-		size int64
-		data io.Reader
-		err  error
+	r         *source
+	base      int64 // Offset of r relative to the RIFF header.
+	lenient   bool
+	maxChunks int
+	chunks    *int  // Chunks read so far, shared with list readers.
+	size      int64 // Size of a seekable input, -1 otherwise.
+	repairs   []Repair
+	chunk     struct {
+		id     string
+		size   int64
+		offset int64
+		data   io.Reader
+		err    error
 	}
 }
 
+// Option configures optional behaviour of a Reader.
+type Option func(*Reader)
+
+// Lenient makes the reader recover from common defects found in files that
+// have not been closed properly. Chunks that are not followed by their padding
+// byte are accepted. If the underlying reader implements io.Seeker, chunk sizes
+// that are zero or otherwise don't match the following data are replaced by
+// the number of bytes up to the end of the file. Otherwise only empty chunks
+// that are not followed by another chunk are extended to the end of the
+// stream. All deviations are reported by Repairs.
+func Lenient() Option {
+	return func(rr *Reader) { rr.lenient = true }
+}
+
+// MaxChunks makes the reader fail with ErrTooManyChunks after n chunks,
+// including the RIFF chunk and chunks inside of lists.
+func MaxChunks(n int) Option {
+	return func(rr *Reader) { rr.maxChunks = n }
+}
+
+// Repair describes a defect the reader worked around in lenient mode.
+type Repair struct {
+	Offset   int64  // Offset of the chunk header.
+	ID       string // ID of the affected chunk.
+	Declared int64  // Size or value found in the file.
+	Actual   int64  // Size or value that has been used instead.
+	Reason   string
+}
+
 // NewReader reads the initial RIFF header and returns a chunk reader and its
 // type.
-func NewReader(r io.Reader) (rr *Reader, riffType string, err error) {
-	rr = &Reader{r: r}
-	if !rr.Next() {
+func NewReader(r io.Reader, opts ...Option) (rr *Reader, riffType string, err error) {
+	rr = &Reader{r: newSource(r), size: -1, chunks: new(int)}
+	for _, opt := range opts {
+		opt(rr)
+	}
+	if rr.lenient {
+		rr.size = rr.r.size()
+	}
+	if !rr.next() {
 		if rr.Error() == nil {
 			return nil, "", errors.Wrap(io.EOF, "unecpected EOF")
 		}
 		return nil, "", errors.Wrap(rr.Error(), "could not read RIFF chunk")
 	}
-	id, _, data := rr.Chunk()
+	id, size, data := rr.Chunk()
 	if id != riffID {
 		return nil, "", errors.Errorf("unexpected chunk id %s", id)
 	}
+	if rr.lenient {
+		// The RIFF chunk contains all other chunks, so its size is of no further
+		// use to the reader.
+		data = rr.r
+		if rr.size >= 0 && size != rr.size-8 {
+			rr.repair(size, rr.size-8, "RIFF size does not match file size")
+		}
+	}
 	t := make([]byte, 4)
-	if _, err := data.Read(t); err != nil {
+	if _, err := io.ReadFull(data, t); err != nil {
 		return nil, "", errors.Wrap(err, "could not read RIFF type")
 	}
 	return rr, string(t), nil
 }
 
+// List reads the type of the current LIST chunk and returns a reader for the
+// chunks it contains. The list reader has to be read until its end before
+// calling Next on the parent reader.
+func (rr *Reader) List() (lr *Reader, listType string, err error) {
+	if rr.chunk.id != listID {
+		return nil, "", errors.Errorf("unexpected chunk id %s", rr.chunk.id)
+	}
+	lr = &Reader{
+		r:         newSource(rr.chunk.data),
+		base:      rr.chunk.offset + 8,
+		lenient:   rr.lenient,
+		maxChunks: rr.maxChunks,
+		chunks:    rr.chunks,
+		size:      -1,
+	}
+	t := make([]byte, 4)
+	if _, err := io.ReadFull(lr.r, t); err != nil {
+		return nil, "", errors.Wrap(err, "could not read list type")
+	}
+	return lr, string(t), nil
+}
+
 // Next returns true until the underlying reader returns an error like EOF. The
 // caller is responsible to read or seek to the end of the chunk before calling
-// Next again.
+// Next again. The padding byte following chunks of an odd size is skipped.
 func (rr *Reader) Next() bool {
+	if rr.chunk.id != riffID && rr.chunk.size%2 == 1 {
+		if err := rr.skipPadding(); err != nil {
+			rr.chunk.err = err
+			return false
+		}
+	}
+	if !rr.next() {
+		return false
+	}
+	if !rr.lenient {
+		return true
+	}
+	var err error
+	if rr.size >= 0 {
+		err = rr.inferSize()
+	} else if rr.chunk.size == 0 {
+		err = rr.inferStream()
+	}
+	if err != nil {
+		rr.chunk.err = err
+		return false
+	}
+	return true
+}
+
+// next reads the next chunk header.
+func (rr *Reader) next() bool {
+	offset := rr.r.offset()
 	header := make([]byte, 8)
-	_, err := rr.r.Read(header)
+	n, err := io.ReadFull(rr.r, header)
 	if err == io.EOF {
 		rr.chunk.err = io.EOF
 		return false
 	}
+	if err == io.ErrUnexpectedEOF && rr.lenient {
+		rr.chunk.offset = rr.base + offset
+		rr.chunk.id = ""
+		rr.repair(int64(n), 0, "ignored trailing bytes")
+		rr.chunk.err = io.EOF
+		return false
+	}
 	if err != nil {
 		rr.chunk.err = errors.Wrap(err, "could not read chunk header")
 		return false
 	}
 	rr.chunk.id = string(header[:4])
 	rr.chunk.size = int64(binary.LittleEndian.Uint32(header[4:]))
+	rr.chunk.offset = rr.base + offset
 	rr.chunk.data = io.LimitReader(rr.r, rr.chunk.size)
+	if *rr.chunks++; rr.maxChunks > 0 && *rr.chunks > rr.maxChunks {
+		rr.chunk.err = errors.Wrapf(ErrTooManyChunks, "more than %d", rr.maxChunks)
+		return false
+	}
 	return rr.chunk.err == nil
 }
 
+// Skip discards the rest of the current chunk. If the underlying reader is an
+// io.Seeker, the data is skipped by seeking instead of reading it.
+func (rr *Reader) Skip() error {
+	data, ok := rr.chunk.data.(*io.LimitedReader)
+	if !ok || data.R != rr.r {
+		_, err := io.Copy(ioutil.Discard, rr.chunk.data)
+		return err
+	}
+	if seeker, ok := rr.r.r.(io.Seeker); ok && len(rr.r.buf) == 0 && data.N > 0 {
+		if _, err := seeker.Seek(data.N, io.SeekCurrent); err != nil {
+			return err
+		}
+		rr.r.n += data.N
+		data.N = 0
+		return nil
+	}
+	_, err := io.Copy(ioutil.Discard, data)
+	return err
+}
+
+// skipPadding consumes the byte that follows chunks of an odd size. In lenient
+// mode, it is kept if it is missing and the next chunk starts right away.
+func (rr *Reader) skipPadding() error {
+	pad := make([]byte, 1)
+	n, err := io.ReadFull(rr.r, pad)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not read padding byte")
+	}
+	if rr.lenient && n == 1 && pad[0] != 0x00 {
+		rr.r.unread(pad)
+		rr.repair(rr.chunk.size, rr.chunk.size, "missing padding byte")
+	}
+	return nil
+}
+
+// inferSize replaces the size of the current chunk by the number of bytes up to
+// the end of the file if it is exceeding the file or if the declared end of the
+// chunk is not followed by another chunk.
+func (rr *Reader) inferSize() error {
+	start := rr.chunk.offset + 8
+	end := start + rr.chunk.size
+	remaining := rr.size - start
+	if end > rr.size {
+		rr.resize(remaining, "chunk exceeds end of file")
+		return nil
+	}
+	if rr.size-end < 8 {
+		return nil
+	}
+	ok, err := rr.r.headerAt(end + rr.chunk.size%2)
+	if err != nil {
+		return errors.Wrap(err, "could not look for next chunk")
+	}
+	if !ok && rr.chunk.size%2 == 1 {
+		ok, err = rr.r.headerAt(end)
+		if err != nil {
+			return errors.Wrap(err, "could not look for next chunk")
+		}
+	}
+	if !ok {
+		rr.resize(remaining, "chunk is not followed by another chunk")
+	}
+	return nil
+}
+
+// inferStream lets an empty chunk of a stream extend to its end if it is not
+// followed by another chunk. The chunks size is reported as -1.
+func (rr *Reader) inferStream() error {
+	header := make([]byte, 8)
+	n, err := io.ReadFull(rr.r, header)
+	rr.r.unread(header[:n])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return errors.Wrap(err, "could not look for next chunk")
+	}
+	if n == 0 || n == 8 && isID(header[:4]) {
+		return nil
+	}
+	rr.repair(0, -1, "chunk is not followed by another chunk")
+	rr.chunk.size = -1
+	rr.chunk.data = rr.r
+	return nil
+}
+
+func (rr *Reader) resize(size int64, reason string) {
+	rr.repair(rr.chunk.size, size, reason)
+	rr.chunk.size = size
+	rr.chunk.data = io.LimitReader(rr.r, size)
+}
+
+func (rr *Reader) repair(declared, actual int64, reason string) {
+	rr.repairs = append(rr.repairs, Repair{
+		Offset:   rr.chunk.offset,
+		ID:       rr.chunk.id,
+		Declared: declared,
+		Actual:   actual,
+		Reason:   reason,
+	})
+}
+
 // Chunk returns the current chunk. This function can be called multiple times.
+// In lenient mode, size is -1 if the chunk extends to the end of a stream.
 func (rr *Reader) Chunk() (id string, size int64, data io.Reader) {
 	return rr.chunk.id, rr.chunk.size, rr.chunk.data
 }
 
+// Offset returns the position of the current chunks header, relative to the
+// beginning of the RIFF file.
+func (rr *Reader) Offset() int64 {
+	return rr.chunk.offset
+}
+
+// Repairs returns the defects that have been worked around so far in lenient
+// mode.
+func (rr *Reader) Repairs() []Repair {
+	return rr.repairs
+}
+
 // Err returns the first non-EOF error.
 func (rr Reader) Error() error {
 	if rr.chunk.err == io.EOF {
@@ -73,3 +308,110 @@ func (rr Reader) Error() error {
 	}
 	return rr.chunk.err
 }
+
+// source wraps the underlying io.Reader and keeps track of the current
+// position.
+type source struct {
+	r    io.Reader
+	base int64
+	n    int64
+	buf  []byte // Bytes that have been read but given back.
+}
+
+func newSource(r io.Reader) *source {
+	s := &source{r: r}
+	if seeker, ok := r.(io.Seeker); ok {
+		if pos, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			s.base = pos
+		}
+	}
+	return s
+}
+
+func (s *source) Read(p []byte) (int, error) {
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	var err error
+	if n < len(p) {
+		var m int
+		m, err = s.r.Read(p[n:])
+		n += m
+		if err == io.EOF && n > 0 {
+			err = nil
+		}
+	}
+	s.n += int64(n)
+	return n, err
+}
+
+// unread gives back bytes, so they are returned by the next call to Read.
+func (s *source) unread(p []byte) {
+	s.buf = append(append([]byte{}, p...), s.buf...)
+	s.n -= int64(len(p))
+}
+
+// offset returns the number of bytes read so far. Seekers are asked directly,
+// since callers are allowed to seek past chunks they are not interested in.
+func (s *source) offset() int64 {
+	if seeker, ok := s.r.(io.Seeker); ok {
+		if pos, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			return pos - s.base - int64(len(s.buf))
+		}
+	}
+	return s.n
+}
+
+// size returns the size of a seekable input or -1.
+func (s *source) size() int64 {
+	seeker, ok := s.r.(io.Seeker)
+	if !ok {
+		return -1
+	}
+	pos, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1
+	}
+	if _, err := seeker.Seek(pos, io.SeekStart); err != nil {
+		return -1
+	}
+	return end - s.base
+}
+
+// headerAt reports if a plausible chunk header is located at offset. The
+// underlying reader has to be an io.Seeker.
+func (s *source) headerAt(offset int64) (bool, error) {
+	seeker := s.r.(io.Seeker)
+	pos, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	if _, err := seeker.Seek(s.base+offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	header := make([]byte, 8)
+	_, err = io.ReadFull(s.r, header)
+	if _, err := seeker.Seek(pos, io.SeekStart); err != nil {
+		return false, err
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return isID(header[:4]), nil
+}
+
+// isID reports if id consists of printable ASCII characters.
+func isID(id []byte) bool {
+	for _, c := range id {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return id[0] != ' '
+}
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"math/rand"
 	"testing"
+	"testing/iotest"
 
 	"github.com/bake/wave/riff"
+	"github.com/pkg/errors"
 )
 
 func exampleInt8WaveReader() io.ReadSeeker {
@@ -53,9 +57,10 @@ func TestReader(t *testing.T) {
 	}
 
 	chunks := []struct {
-		id   string
-		size int64
-	}{{"fmt ", 16}, {"slnt", 4}, {"data", 28}, {"data", 16}}
+		id     string
+		size   int64
+		offset int64
+	}{{"fmt ", 16, 12}, {"slnt", 4, 36}, {"data", 28, 48}, {"data", 16, 84}}
 
 	for i := 0; rr.Next(); i++ {
 		id, size, _ := rr.Chunk()
@@ -65,6 +70,9 @@ func TestReader(t *testing.T) {
 		if size != chunks[i].size {
 			t.Fatalf("expected size to be %d, got %d", chunks[i].size, size)
 		}
+		if offset := rr.Offset(); offset != chunks[i].offset {
+			t.Fatalf("expected offset to be %d, got %d", chunks[i].offset, offset)
+		}
 		if _, err := r.Seek(size, io.SeekCurrent); err != nil {
 			t.Fatalf("could not seek chunk: %v", err)
 		}
@@ -84,7 +92,7 @@ func ExampleReader() {
 	for rr.Next() {
 		id, size, data := rr.Chunk()
 		body := make([]byte, size)
-		if _, err := data.Read(body); err != nil {
+		if _, err := io.ReadFull(data, body); err != nil {
 			log.Fatal(err)
 		}
 		switch id {
@@ -105,3 +113,224 @@ func ExampleReader() {
 	// data: ...
 	// data: ...
 }
+
+func TestLenientReader(t *testing.T) {
+	data := []byte{
+		// R,    I,    F,    F,                      0,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x00, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+		// s,    l,    n,    t,                      3,
+		0x73, 0x6c, 0x6e, 0x74, 0x03, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff,
+		// d,    a,    t,    a,                      0,
+		0x64, 0x61, 0x74, 0x61, 0x00, 0x00, 0x00, 0x00,
+		0x24, 0x17, 0x1e, 0xf3, 0x3c, 0x13, 0x3c, 0x14, 0x16, 0xf9, 0x18, 0xf9,
+	}
+	tt := []struct {
+		name    string
+		r       io.Reader
+		sizes   []int64
+		repairs []riff.Repair
+	}{
+		{
+			name:  "seekable",
+			r:     bytes.NewReader(data),
+			sizes: []int64{3, 12},
+			repairs: []riff.Repair{
+				{Offset: 0, ID: "RIFF", Declared: 0, Actual: 35, Reason: "RIFF size does not match file size"},
+				{Offset: 12, ID: "slnt", Declared: 3, Actual: 3, Reason: "missing padding byte"},
+				{Offset: 23, ID: "data", Declared: 0, Actual: 12, Reason: "chunk is not followed by another chunk"},
+			},
+		},
+		{
+			name:  "not seekable",
+			r:     struct{ io.Reader }{bytes.NewReader(data)},
+			sizes: []int64{3, -1},
+			repairs: []riff.Repair{
+				{Offset: 12, ID: "slnt", Declared: 3, Actual: 3, Reason: "missing padding byte"},
+				{Offset: 23, ID: "data", Declared: 0, Actual: -1, Reason: "chunk is not followed by another chunk"},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr, _, err := riff.NewReader(tc.r, riff.Lenient())
+			if err != nil {
+				t.Fatalf("could not create riff reader: %v", err)
+			}
+			var sizes []int64
+			for rr.Next() {
+				_, size, data := rr.Chunk()
+				if _, err := ioutil.ReadAll(data); err != nil {
+					t.Fatalf("could not read chunk: %v", err)
+				}
+				sizes = append(sizes, size)
+			}
+			if err := rr.Error(); err != nil {
+				t.Fatalf("could not read chunks: %v", err)
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tc.sizes) {
+				t.Fatalf("expected sizes to be %v, got %v", tc.sizes, sizes)
+			}
+			if fmt.Sprint(rr.Repairs()) != fmt.Sprint(tc.repairs) {
+				t.Fatalf("expected repairs to be\n%v, got\n%v", tc.repairs, rr.Repairs())
+			}
+		})
+	}
+}
+
+// randomReader returns at most a random number of bytes on each read.
+type randomReader struct {
+	r   io.Reader
+	rnd *rand.Rand
+}
+
+func (r *randomReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return r.r.Read(p)
+	}
+	return r.r.Read(p[:1+r.rnd.Intn(len(p))])
+}
+
+func TestShortReads(t *testing.T) {
+	readers := map[string]func(io.Reader) io.Reader{
+		"one byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+		"data err": iotest.DataErrReader,
+		"random": func(r io.Reader) io.Reader {
+			return &randomReader{r, rand.New(rand.NewSource(1))}
+		},
+	}
+	readChunks := func(r io.Reader) ([]string, error) {
+		rr, _, err := riff.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		var chunks []string
+		for rr.Next() {
+			id, _, data := rr.Chunk()
+			body, err := ioutil.ReadAll(data)
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, fmt.Sprintf("%s: % x", id, body))
+		}
+		return chunks, rr.Error()
+	}
+	out, err := readChunks(exampleInt16WaveReader())
+	if err != nil {
+		t.Fatalf("could not read chunks: %v", err)
+	}
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			chunks, err := readChunks(newReader(exampleInt16WaveReader()))
+			if err != nil {
+				t.Fatalf("could not read chunks: %v", err)
+			}
+			if fmt.Sprint(chunks) != fmt.Sprint(out) {
+				t.Fatalf("expected chunks to be\n%v, got\n%v", out, chunks)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	r := bytes.NewReader([]byte{
+		// R,    I,    F,    F,                     26,    T,    E,    S,    T,
+		0x52, 0x49, 0x46, 0x46, 0x1a, 0x00, 0x00, 0x00, 0x54, 0x45, 0x53, 0x54,
+		// L,    I,    S,    T,                     14,    t,    e,    s,    t,
+		0x4c, 0x49, 0x53, 0x54, 0x0e, 0x00, 0x00, 0x00, 0x74, 0x65, 0x73, 0x74,
+		// d,    a,    t,    a,                      1,    1,  pad,
+		0x64, 0x61, 0x74, 0x61, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00,
+	})
+	rr, _, err := riff.NewReader(r)
+	if err != nil {
+		t.Fatalf("could not create riff reader: %v", err)
+	}
+	if !rr.Next() {
+		t.Fatalf("could not read list: %v", rr.Error())
+	}
+	lr, listType, err := rr.List()
+	if err != nil {
+		t.Fatalf("could not read list: %v", err)
+	}
+	if listType != "test" {
+		t.Fatalf("expected list type to be \"test\", got \"%s\"", listType)
+	}
+	if !lr.Next() {
+		t.Fatalf("could not read chunk: %v", lr.Error())
+	}
+	id, size, data := lr.Chunk()
+	if id != "data" || size != 1 || lr.Offset() != 24 {
+		t.Fatalf("unexpected chunk %s of size %d at %d", id, size, lr.Offset())
+	}
+	if _, err := ioutil.ReadAll(data); err != nil {
+		t.Fatalf("could not read chunk: %v", err)
+	}
+	if lr.Next() {
+		t.Fatal("expected end of list")
+	}
+	if err := lr.Error(); err != nil {
+		t.Fatalf("could not read list: %v", err)
+	}
+	if rr.Next() {
+		t.Fatal("expected end of file")
+	}
+}
+
+func TestMaxChunks(t *testing.T) {
+	for _, tc := range []struct {
+		max int
+		err bool
+	}{{5, false}, {4, true}} {
+		rr, _, err := riff.NewReader(exampleInt16WaveReader(), riff.MaxChunks(tc.max))
+		if err != nil {
+			t.Fatalf("could not create riff reader: %v", err)
+		}
+		for rr.Next() {
+			if err := rr.Skip(); err != nil {
+				t.Fatalf("could not skip chunk: %v", err)
+			}
+		}
+		if err := rr.Error(); errors.Is(err, riff.ErrTooManyChunks) != tc.err {
+			t.Fatalf("expected error with at most %d chunks to be %v, got %v", tc.max, tc.err, err)
+		}
+	}
+}
+
+// seekCounter counts the bytes read from a seeker.
+type seekCounter struct {
+	io.ReadSeeker
+	n int
+}
+
+func (r *seekCounter) Read(p []byte) (int, error) {
+	n, err := r.ReadSeeker.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestSkip(t *testing.T) {
+	body := writeChunks(t, []testChunk{{"junk", make([]byte, 1<<20+1)}, {"data", []byte{0x01}}})
+	for name, r := range map[string]io.Reader{
+		"seeker": &seekCounter{ReadSeeker: bytes.NewReader(body)},
+		"stream": bytes.NewBuffer(body),
+	} {
+		rr, _, err := riff.NewReader(r)
+		if err != nil {
+			t.Fatalf("could not create riff reader: %v", err)
+		}
+		var ids []string
+		for rr.Next() {
+			id, _, _ := rr.Chunk()
+			ids = append(ids, id)
+			if err := rr.Skip(); err != nil {
+				t.Fatalf("could not skip %s: %v", id, err)
+			}
+		}
+		if err := rr.Error(); err != nil || fmt.Sprint(ids) != "[junk data]" {
+			t.Fatalf("%s: expected chunks junk and data, got %v: %v", name, ids, err)
+		}
+		if c, ok := r.(*seekCounter); ok && c.n > 1<<10 {
+			t.Fatalf("expected chunks to be skipped by seeking, read %d bytes", c.n)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package riff_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/bake/wave/riff"
+	"github.com/orcaman/writerseeker"
+)
+
+type testChunk struct {
+	id   string
+	data []byte
+}
+
+func TestRoundTrip(t *testing.T) {
+	tt := []struct {
+		name   string
+		chunks []testChunk
+	}{
+		{"even", []testChunk{{"dat1", []byte{0x01, 0x02}}, {"dat2", []byte{0x03, 0x04, 0x05, 0x06}}}},
+		{"odd", []testChunk{{"dat1", []byte{0x01, 0x02, 0x03}}, {"dat2", []byte{0x04, 0x05, 0x06}}}},
+		{"single byte", []testChunk{{"dat1", []byte{0xff}}, {"dat2", []byte{0xff}}, {"dat3", []byte{0xff}}}},
+		{"empty", []testChunk{{"dat1", []byte{}}, {"dat2", []byte{0x01}}, {"dat3", []byte{}}}},
+		{"odd last", []testChunk{{"dat1", []byte{0x01, 0x02}}, {"dat2", []byte{0x03, 0x04, 0x05}}}},
+		{"odd first", []testChunk{{"dat1", []byte{0x01}}, {"dat2", []byte{0x02, 0x03, 0x04, 0x05}}}},
+	}
+	readers := map[string]func([]byte) io.Reader{
+		"bytes":    func(b []byte) io.Reader { return bytes.NewReader(b) },
+		"stream":   func(b []byte) io.Reader { return struct{ io.Reader }{bytes.NewReader(b)} },
+		"lenient":  func(b []byte) io.Reader { return bytes.NewReader(b) },
+		"buffered": func(b []byte) io.Reader { return bytes.NewBuffer(b) },
+	}
+	for _, tc := range tt {
+		body := writeChunks(t, tc.chunks)
+		for name, newReader := range readers {
+			t.Run(tc.name+"/"+name, func(t *testing.T) {
+				var opts []riff.Option
+				if name == "lenient" {
+					opts = append(opts, riff.Lenient())
+				}
+				rr, riffType, err := riff.NewReader(newReader(body), opts...)
+				if err != nil {
+					t.Fatalf("could not create riff reader: %v", err)
+				}
+				if riffType != "TEST" {
+					t.Fatalf("expected RIFF type to be \"TEST\", got \"%s\"", riffType)
+				}
+				var chunks []testChunk
+				for rr.Next() {
+					id, _, data := rr.Chunk()
+					body, err := ioutil.ReadAll(data)
+					if err != nil {
+						t.Fatalf("could not read %s: %v", id, err)
+					}
+					chunks = append(chunks, testChunk{id, body})
+				}
+				if err := rr.Error(); err != nil {
+					t.Fatalf("could not read chunks: %v", err)
+				}
+				if fmt.Sprintf("%x", chunks) != fmt.Sprintf("%x", tc.chunks) {
+					t.Fatalf("expected chunks to be\n%x, got\n%x", tc.chunks, chunks)
+				}
+				if repairs := rr.Repairs(); len(repairs) > 0 {
+					t.Fatalf("expected no repairs, got %v", repairs)
+				}
+			})
+		}
+	}
+}
+
+func writeChunks(t *testing.T, chunks []testChunk) []byte {
+	t.Helper()
+	ws := &writerseeker.WriterSeeker{}
+	rw, err := riff.NewWriter(ws, "TEST")
+	if err != nil {
+		t.Fatalf("could not create new riff writer: %v", err)
+	}
+	for _, c := range chunks {
+		cw, err := rw.Chunk(c.id)
+		if err != nil {
+			t.Fatalf("could not create chunk %s: %v", c.id, err)
+		}
+		if _, err := cw.Write(c.data); err != nil {
+			t.Fatalf("could not write to %s: %v", c.id, err)
+		}
+		if err := cw.Close(); err != nil {
+			t.Fatalf("could not close %s: %v", c.id, err)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatalf("could not close riff: %v", err)
+	}
+	body, err := ioutil.ReadAll(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not read riff: %v", err)
+	}
+	return body
+}
@@ -0,0 +1,110 @@
+package wave_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/orcaman/writerseeker"
+)
+
+func TestRoundTrip(t *testing.T) {
+	layouts := []struct {
+		name    string
+		bext    bool
+		cues    bool
+		compact bool
+	}{
+		{"plain", false, false, false},
+		{"bext", true, false, false},
+		{"cues", false, true, false},
+		{"silence", false, false, true},
+		{"all", true, true, true},
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for _, bits := range []int{8, 16, 24, 32} {
+		for _, chans := range []int{1, 2, 3, 6} {
+			for _, layout := range layouts {
+				name := fmt.Sprintf("%d bits/%d channels/%s", bits, chans, layout.name)
+				t.Run(name, func(t *testing.T) {
+					format := wave.Format{
+						AudioFormat:   1,
+						NumChans:      uint16(chans),
+						SampleRate:    44100,
+						ByteRate:      uint32(44100 * chans * bits / 8),
+						BlockAlign:    uint16(chans * bits / 8),
+						BitsPerSample: uint16(bits),
+					}
+					// Random samples of an odd number of frames with a run of
+					// silence in between.
+					min, max := -1<<uint(bits-1), 1<<uint(bits-1)-1
+					silence := 0
+					if bits == 8 {
+						min, max, silence = 0, 255, 128
+					}
+					samples := make([]int, 101*chans)
+					for i := range samples {
+						samples[i] = min + int(rnd.Int63n(int64(max-min)+1))
+						if i >= 30*chans && i < 60*chans {
+							samples[i] = silence
+						}
+					}
+					var opts []wave.WriterOption
+					bext := &wave.Bext{Description: name, TimeReference: 44100, CodingHistory: "A=PCM\r\n"}
+					if layout.bext {
+						opts = append(opts, wave.BextChunk(bext))
+					}
+					if layout.compact {
+						opts = append(opts, wave.CompactSilence(10))
+					}
+					cues := []wave.Cue{{ID: 1, Frame: 0}, {ID: 2, Frame: 50}}
+
+					ws := &writerseeker.WriterSeeker{}
+					wavw, err := wave.NewWriter(ws, format, opts...)
+					if err != nil {
+						t.Fatalf("could not create wave writer: %v", err)
+					}
+					if err := wavw.Samples(samples); err != nil {
+						t.Fatalf("could not write samples: %v", err)
+					}
+					if layout.cues {
+						wavw.SetCues(cues)
+					}
+					if err := wavw.Close(); err != nil {
+						t.Fatalf("could not close wave writer: %v", err)
+					}
+
+					wavr, err := wave.NewReader(ws.Reader(), wave.Strict())
+					if err != nil {
+						t.Fatalf("could not create wave reader: %v", err)
+					}
+					if wavr.Format != format {
+						t.Fatalf("expected format to be %+v, got %+v", format, wavr.Format)
+					}
+					got, err := wavr.Samples()
+					if err != nil {
+						t.Fatalf("could not read samples: %v", err)
+					}
+					if fmt.Sprint(got) != fmt.Sprint(samples) {
+						t.Fatalf("expected samples to be\n%v, got\n%v", samples, got)
+					}
+					b, err := wavr.Bext()
+					if err != nil {
+						t.Fatalf("could not read bext chunk: %v", err)
+					}
+					if layout.bext && fmt.Sprint(b) != fmt.Sprint(bext) || !layout.bext && b != nil {
+						t.Fatalf("expected bext chunk to be %v, got %v", bext, b)
+					}
+					c, err := wavr.Cues()
+					if err != nil {
+						t.Fatalf("could not read cues: %v", err)
+					}
+					if layout.cues && fmt.Sprint(c) != fmt.Sprint(cues) || !layout.cues && len(c) > 0 {
+						t.Fatalf("expected cues to be %v, got %v", cues, c)
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package wave
+
+import (
+	"github.com/bake/wave/riff"
+	"github.com/pkg/errors"
+)
+
+// Segment describes a data chunk or a silence chunk inside a wave list and the
+// frames it contains.
+type Segment struct {
+	Index  int   // Index of the chunk.
+	Offset int64 // Offset of the chunk header relative to the RIFF header.
+	Size   int64 // Size of the chunk in bytes.
+	Start  int64 // First frame in the chunk.
+	End    int64 // First frame after the chunk.
+	Silent bool  // True for silence chunks.
+}
+
+// Frames returns the number of frames in the segment.
+func (s Segment) Frames() int64 {
+	return s.End - s.Start
+}
+
+// Segment returns the chunk the reader is currently in. Its index is -1
+// before the first data chunk has been reached.
+func (wavr *Reader) Segment() Segment {
+	return wavr.segment
+}
+
+// nextSegment returns the segment following prev.
+func (wavr *Reader) nextSegment(prev Segment, offset, size, frames int64) Segment {
+	start := prev.End
+	if prev.Index < 0 {
+		start = 0
+	}
+	return Segment{
+		Index:  prev.Index + 1,
+		Offset: offset,
+		Size:   size,
+		Start:  start,
+		End:    start + frames,
+	}
+}
+
+// Segments lists all data chunks and silence chunks inside of wave lists. This
+// requires the underlying reader to be an io.Seeker. The position of the
+// reader is not changed.
+func (wavr *Reader) Segments() ([]Segment, error) {
+	var segments []Segment
+	segment := Segment{Index: -1}
+	add := func(rr *riff.Reader, inList bool) error {
+		next, ok, err := wavr.chunkSegment(segment, rr, inList)
+		if ok {
+			segment = next
+			segments = append(segments, segment)
+		}
+		return err
+	}
+	err := wavr.scan(func(rr *riff.Reader) error {
+		for rr.Next() {
+			id, _, _ := rr.Chunk()
+			if id != "LIST" {
+				if err := add(rr, false); err != nil {
+					return err
+				}
+				if err := rr.Skip(); err != nil {
+					return errors.Wrapf(err, "could not skip %s chunk", id)
+				}
+				continue
+			}
+			lr, t, err := rr.List()
+			if err == nil && t == "wavl" {
+				for lr.Next() {
+					if err := add(lr, true); err != nil {
+						return err
+					}
+					if err := lr.Skip(); err != nil {
+						id, _, _ := lr.Chunk()
+						return errors.Wrapf(err, "could not skip %s chunk", id)
+					}
+				}
+				err = lr.Error()
+			}
+			if err != nil {
+				return errors.Wrap(err, "could not read list")
+			}
+			if err := rr.Skip(); err != nil {
+				return errors.Wrap(err, "could not skip list")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+// chunkSegment returns the segment following prev if the current chunk of rr
+// contains samples. Silence chunks are only valid inside of wave lists.
+func (wavr *Reader) chunkSegment(prev Segment, rr *riff.Reader, inList bool) (Segment, bool, error) {
+	id, size, data := rr.Chunk()
+	switch {
+	case id == "data":
+		frames := int64(0)
+		if wavr.Format.BlockAlign > 0 {
+			frames = size / int64(wavr.Format.BlockAlign)
+		}
+		return wavr.nextSegment(prev, rr.Offset(), size, frames), true, nil
+	case id == "slnt" && inList:
+		frames, err := decodeSilence(data)
+		if err != nil {
+			return prev, false, errors.Wrap(err, "could not decode silence chunk")
+		}
+		segment := wavr.nextSegment(prev, rr.Offset(), size, frames)
+		segment.Silent = true
+		return segment, true, nil
+	}
+	return prev, false, nil
+}
+
+// NumFrames returns the number of frames in all data chunks. This requires the
+// underlying reader to be an io.Seeker, otherwise -1 is returned. The position
+// of the reader is not changed.
+func (wavr *Reader) NumFrames() (int64, error) {
+	segments, err := wavr.Segments()
+	if err == ErrNotSeekable {
+		return -1, nil
+	}
+	if err != nil || len(segments) == 0 {
+		return 0, err
+	}
+	return segments[len(segments)-1].End, nil
+}
@@ -0,0 +1,151 @@
+package wave_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/riff"
+	"github.com/orcaman/writerseeker"
+)
+
+func TestSegments(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	out := []wave.Segment{
+		{Index: 0, Offset: 48, Size: 28, Start: 0, End: 7},
+		{Index: 1, Offset: 84, Size: 16, Start: 7, End: 11},
+	}
+	segments, err := wavr.Segments()
+	if err != nil {
+		t.Fatalf("could not list segments: %v", err)
+	}
+	if fmt.Sprint(segments) != fmt.Sprint(out) {
+		t.Fatalf("expected segments to be\n%v, got\n%v", out, segments)
+	}
+
+	var indices []int
+	for {
+		if _, err := wavr.Sample(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("could not read sample: %v", err)
+		}
+		indices = append(indices, wavr.Segment().Index)
+	}
+	if exp := "[0 0 0 0 0 0 0 0 0 0 0 0 0 0 1 1 1 1 1 1 1 1]"; fmt.Sprint(indices) != exp {
+		t.Fatalf("expected segment indices to be\n%v, got\n%v", exp, indices)
+	}
+	if wavr.Segment() != out[1] {
+		t.Fatalf("expected last segment to be %v, got %v", out[1], wavr.Segment())
+	}
+
+	if _, err := (&wave.Reader{}).Segments(); err != wave.ErrNotSeekable {
+		t.Fatalf("expected %v, got %v", wave.ErrNotSeekable, err)
+	}
+}
+
+func TestSegmentsError(t *testing.T) {
+	data := []byte{
+		// R,    I,    F,    F,                     64,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x40, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+
+		// f,    m,    t,    ␣,                     16,          1,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		//                8000,                   8000,          1,          8,
+		0x40, 0x1f, 0x00, 0x00, 0x40, 0x1f, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00,
+
+		// d,    a,    t,    a,                      5,    1,    2,    3,    4,
+		0x64, 0x61, 0x74, 0x61, 0x05, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		//   5,  pad,
+		0x05, 0x00,
+
+		// L,    I,    S,    T,                     14,    w,    a,    v,    l,
+		0x4c, 0x49, 0x53, 0x54, 0x0e, 0x00, 0x00, 0x00, 0x77, 0x61, 0x76, 0x6c,
+		// s,    l,    n,    t,                      2, truncated,
+		0x73, 0x6c, 0x6e, 0x74, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	wavr, err := wave.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	if _, err := wavr.Segments(); err == nil {
+		t.Fatalf("expected an error for a truncated silence chunk")
+	}
+	var samples []int
+	for i := 0; i < 5; i++ {
+		s, err := wavr.Sample()
+		if err != nil {
+			t.Fatalf("could not read sample: %v", err)
+		}
+		samples = append(samples, s)
+	}
+	if fmt.Sprint(samples) != "[1 2 3 4 5]" {
+		t.Fatalf("expected samples to be [1 2 3 4 5], got %v", samples)
+	}
+}
+
+func TestManySegments(t *testing.T) {
+	const n = 1000
+	ws := &writerseeker.WriterSeeker{}
+	rw, err := riff.NewWriter(ws, "WAVE")
+	if err != nil {
+		t.Fatalf("could not create riff writer: %v", err)
+	}
+	type chunk struct {
+		id   string
+		data []byte
+	}
+	chunks := []chunk{{"fmt ", []byte{
+		//         1,          1,                  22050,                  22050,
+		0x01, 0x00, 0x01, 0x00, 0x22, 0x56, 0x00, 0x00, 0x22, 0x56, 0x00, 0x00,
+		//         1,          8,
+		0x01, 0x00, 0x08, 0x00,
+	}}}
+	for i := 0; i < n; i++ {
+		chunks = append(chunks, chunk{"data", []byte{byte(i)}}, chunk{"junk", []byte{0xff}})
+	}
+	for _, c := range chunks {
+		cw, err := rw.Chunk(c.id)
+		if err != nil {
+			t.Fatalf("could not create chunk: %v", err)
+		}
+		if _, err := cw.Write(c.data); err != nil {
+			t.Fatalf("could not write chunk: %v", err)
+		}
+		if err := cw.Close(); err != nil {
+			t.Fatalf("could not close chunk: %v", err)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatalf("could not close riff writer: %v", err)
+	}
+	body, _ := ioutil.ReadAll(ws.Reader())
+
+	wavr, err := wave.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	segments, err := wavr.Segments()
+	if err != nil {
+		t.Fatalf("could not list segments: %v", err)
+	}
+	if len(segments) != n {
+		t.Fatalf("expected %d segments, got %d", n, len(segments))
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if len(samples) != n || samples[n-1] != (n-1)%256 {
+		t.Fatalf("expected %d samples, got %d", n, len(samples))
+	}
+	if wavr.Segment() != segments[n-1] {
+		t.Fatalf("expected last segment to be %v, got %v", segments[n-1], wavr.Segment())
+	}
+}
@@ -0,0 +1,53 @@
+package wave
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// silence is an endless reader of silent samples.
+type silence byte
+
+// newSilence returns a reader of silent samples in the given format. Unsigned 8
+// bit samples are centered around 0x80, all others around zero.
+func newSilence(format Format) silence {
+	if format.BitsPerSample == 8 {
+		return silence(0x80)
+	}
+	return silence(0x00)
+}
+
+// SilenceReader returns an endless reader of silent samples in the given
+// format.
+func SilenceReader(format Format) io.Reader {
+	return newSilence(format)
+}
+
+func (s silence) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(s)
+	}
+	return len(p), nil
+}
+
+// isSilent reports if all samples of a frame are silent.
+func (s silence) isSilent(frame []byte) bool {
+	for _, b := range frame {
+		if b != byte(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeSilence decodes the number of silent frames in a silence chunk.
+func decodeSilence(r io.Reader) (int64, error) {
+	var frames uint32
+	err := binary.Read(r, binary.LittleEndian, &frames)
+	return int64(frames), err
+}
+
+// encodeSilence encodes the number of silent frames in a silence chunk.
+func encodeSilence(w io.Writer, frames int64) error {
+	return binary.Write(w, binary.LittleEndian, uint32(frames))
+}
@@ -0,0 +1,146 @@
+package wave_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/riff"
+	"github.com/orcaman/writerseeker"
+)
+
+func exampleWaveList() []byte {
+	return []byte{
+		// R,    I,    F,    F,                     74,    W,    A,    V,    E,
+		0x52, 0x49, 0x46, 0x46, 0x4a, 0x00, 0x00, 0x00, 0x57, 0x41, 0x56, 0x45,
+
+		// f,    m,    t,    ␣,                     16,          1,          1,
+		0x66, 0x6d, 0x74, 0x20, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		//               22050,                  44100,          2,         16,
+		0x22, 0x56, 0x00, 0x00, 0x44, 0xac, 0x00, 0x00, 0x02, 0x00, 0x10, 0x00,
+
+		// L,    I,    S,    T,                     38,    w,    a,    v,    l,
+		0x4c, 0x49, 0x53, 0x54, 0x26, 0x00, 0x00, 0x00, 0x77, 0x61, 0x76, 0x6c,
+		// d,    a,    t,    a,                      4,       5924,      -3298,
+		0x64, 0x61, 0x74, 0x61, 0x04, 0x00, 0x00, 0x00, 0x24, 0x17, 0x1e, 0xf3,
+		// s,    l,    n,    t,                      4,                      3,
+		0x73, 0x6c, 0x6e, 0x74, 0x04, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00,
+		// d,    a,    t,    a,                      2,       4924,
+		0x64, 0x61, 0x74, 0x61, 0x02, 0x00, 0x00, 0x00, 0x3c, 0x13,
+	}
+}
+
+func TestReaderWaveList(t *testing.T) {
+	wavr, err := wave.NewReader(bytes.NewReader(exampleWaveList()), wave.Strict())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	segments, err := wavr.Segments()
+	if err != nil {
+		t.Fatalf("could not list segments: %v", err)
+	}
+	out := []wave.Segment{
+		{Index: 0, Offset: 48, Size: 4, Start: 0, End: 2},
+		{Index: 1, Offset: 60, Size: 4, Start: 2, End: 5, Silent: true},
+		{Index: 2, Offset: 72, Size: 2, Start: 5, End: 6},
+	}
+	if fmt.Sprint(segments) != fmt.Sprint(out) {
+		t.Fatalf("expected segments to be\n%v, got\n%v", out, segments)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	if exp := []int{5924, -3298, 0, 0, 0, 4924}; fmt.Sprint(samples) != fmt.Sprint(exp) {
+		t.Fatalf("expected samples to be\n%v, got\n%v", exp, samples)
+	}
+
+	wavr, err = wave.NewReader(bytes.NewReader(exampleWaveList()))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	body, err := ioutil.ReadAll(wavr)
+	if err != nil {
+		t.Fatalf("could not read data: %v", err)
+	}
+	exp := []byte{0x24, 0x17, 0x1e, 0xf3, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x3c, 0x13}
+	if fmt.Sprintf("% x", body) != fmt.Sprintf("% x", exp) {
+		t.Fatalf("expected body to be\n% x, got\n% x\n", exp, body)
+	}
+}
+
+func TestWriterCompactSilence(t *testing.T) {
+	tt := []struct {
+		bps     uint16
+		samples []int
+		chunks  string
+	}{
+		{16, []int{1, 0, 0, 0, 2, 0, 3, 0, 0}, "[data:2 slnt:4 data:6 slnt:4]"},
+		{16, []int{0, 0, 1, 2}, "[slnt:4 data:4]"},
+		{16, []int{0, 1, 0}, "[data:6]"},
+		{8, []int{1, 128, 128, 128, 2}, "[data:1 slnt:4 data:1]"},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprint(tc.samples), func(t *testing.T) {
+			format := wave.Format{
+				AudioFormat:   1,
+				NumChans:      1,
+				SampleRate:    22050,
+				ByteRate:      22050 * uint32(tc.bps) / 8,
+				BlockAlign:    tc.bps / 8,
+				BitsPerSample: tc.bps,
+			}
+			ws := &writerseeker.WriterSeeker{}
+			wavw, err := wave.NewWriter(ws, format, wave.CompactSilence(2))
+			if err != nil {
+				t.Fatalf("could not create wave writer: %v", err)
+			}
+			if err := wavw.Samples(tc.samples); err != nil {
+				t.Fatalf("could not write samples: %v", err)
+			}
+			if err := wavw.Close(); err != nil {
+				t.Fatalf("could not close wave writer: %v", err)
+			}
+			body, _ := ioutil.ReadAll(ws.Reader())
+
+			rr, _, err := riff.NewReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("could not create riff reader: %v", err)
+			}
+			var chunks []string
+			for rr.Next() {
+				id, _, data := rr.Chunk()
+				if id != "LIST" {
+					ioutil.ReadAll(data)
+					continue
+				}
+				lr, _, err := rr.List()
+				if err != nil {
+					t.Fatalf("could not read list: %v", err)
+				}
+				for lr.Next() {
+					id, size, data := lr.Chunk()
+					ioutil.ReadAll(data)
+					chunks = append(chunks, fmt.Sprintf("%s:%d", id, size))
+				}
+			}
+			if fmt.Sprint(chunks) != tc.chunks {
+				t.Fatalf("expected chunks to be %s, got %v", tc.chunks, chunks)
+			}
+
+			wavr, err := wave.NewReader(bytes.NewReader(body), wave.Strict())
+			if err != nil {
+				t.Fatalf("could not create wave reader: %v", err)
+			}
+			samples, err := wavr.Samples()
+			if err != nil {
+				t.Fatalf("could not read samples: %v", err)
+			}
+			if fmt.Sprint(samples) != fmt.Sprint(tc.samples) {
+				t.Fatalf("expected samples to be\n%v, got\n%v", tc.samples, samples)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package wave
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// BlockSize is the number of frames read at once by ReadBlocks.
+const BlockSize = 4096
+
+// Source produces interleaved frames of samples in the range [-1, 1).
+type Source interface {
+	// FrameFormat returns the format of the frames.
+	FrameFormat() Format
+	// Floats reads samples into dst. It returns the number of samples read
+	// and io.EOF after the last one.
+	Floats(dst []float64) (int, error)
+}
+
+// Sink consumes interleaved frames of samples in the range [-1, 1).
+type Sink interface {
+	// FrameFormat returns the format of the frames.
+	FrameFormat() Format
+	// Floats writes samples.
+	Floats(src []float64) error
+}
+
+// FrameFormat returns the format of the file.
+func (wavr *Reader) FrameFormat() Format {
+	return wavr.Format
+}
+
+// FrameFormat returns the format of the file.
+func (wavw *Writer) FrameFormat() Format {
+	return wavw.fmt
+}
+
+var (
+	_ Source = (*Reader)(nil)
+	_ Sink   = (*Writer)(nil)
+)
+
+// ReadBlocks reads blocks of up to BlockSize frames from src until EOF or until
+// ctx is done and passes them to fn. The block is reused between calls.
+func ReadBlocks(ctx context.Context, src Source, fn func(block []float64) error) error {
+	format := src.FrameFormat()
+	if err := format.CheckFrames(); err != nil {
+		return err
+	}
+	buf := make([]float64, BlockSize*int(format.NumChans))
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := src.Floats(buf)
+		if n > 0 {
+			if err := fn(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "could not read samples")
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package wave_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/internal/wavetest"
+	"github.com/pkg/errors"
+)
+
+func TestReadBlocks(t *testing.T) {
+	format := wavetest.Format(2, 8000, 16)
+	src := wavetest.Samples(t, format, make([]int, 2*wave.BlockSize+2))
+	var sizes []int
+	err := wave.ReadBlocks(context.Background(), src, func(block []float64) error {
+		sizes = append(sizes, len(block))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("could not read blocks: %v", err)
+	}
+	if exp := "[8192 2]"; fmt.Sprint(sizes) != exp {
+		t.Fatalf("expected block sizes to be %s, got %v", exp, sizes)
+	}
+
+	errStop := errors.New("stop")
+	src = wavetest.Samples(t, format, []int{1, 2})
+	if err := wave.ReadBlocks(context.Background(), src, func([]float64) error { return errStop }); err != errStop {
+		t.Fatalf("expected %v, got %v", errStop, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := wave.ReadBlocks(ctx, src, func([]float64) error { return nil }); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := wave.ReadBlocks(context.Background(), wavetest.NoChannels(t), func([]float64) error { return nil }); !errors.Is(err, wave.ErrUnsupportedFormat) {
+		t.Fatalf("expected %v, got %v", wave.ErrUnsupportedFormat, err)
+	}
+}
@@ -0,0 +1,357 @@
+// Package waveform generates overviews of WAVE files for drawing their
+// waveform. Overviews can be stored in the binary and JSON formats of
+// audiowaveform, which are understood by common waveform renderers.
+package waveform
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/bake/wave"
+	"github.com/pkg/errors"
+)
+
+// flag8Bit is set in the flags of the binary format if values are 8 bits.
+const flag8Bit = 1
+
+// Waveform holds the minimum and maximum sample per channel for each pixel.
+type Waveform struct {
+	SampleRate      int
+	SamplesPerPixel int
+	Channels        int
+	Bits            int     // Resolution of the values, 8 or 16.
+	Data            []int16 // Minimum and maximum per channel per pixel.
+}
+
+// Len returns the number of pixels.
+func (w *Waveform) Len() int {
+	if w.Channels == 0 {
+		return 0
+	}
+	return len(w.Data) / 2 / w.Channels
+}
+
+// validate checks that the waveform has pixels of whole frames.
+func (w *Waveform) validate() error {
+	if w.SamplesPerPixel <= 0 {
+		return errors.Errorf("invalid number of samples per pixel %d", w.SamplesPerPixel)
+	}
+	if w.Channels <= 0 {
+		return errors.Errorf("invalid number of channels %d", w.Channels)
+	}
+	if len(w.Data)%(2*w.Channels) != 0 {
+		return errors.Errorf("%d values are not a multiple of %d channels", len(w.Data), w.Channels)
+	}
+	return nil
+}
+
+// Zoom returns a waveform with samplesPerPixel frames per pixel, which has to
+// be a multiple of the waveforms frames per pixel.
+func (w *Waveform) Zoom(samplesPerPixel int) (*Waveform, error) {
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	if samplesPerPixel <= 0 || samplesPerPixel%w.SamplesPerPixel != 0 {
+		return nil, errors.Errorf("%d samples per pixel are not a multiple of %d", samplesPerPixel, w.SamplesPerPixel)
+	}
+	factor := samplesPerPixel / w.SamplesPerPixel
+	zoomed := &Waveform{
+		SampleRate:      w.SampleRate,
+		SamplesPerPixel: samplesPerPixel,
+		Channels:        w.Channels,
+		Bits:            w.Bits,
+	}
+	width := 2 * w.Channels
+	for i := 0; i < len(w.Data); i += factor * width {
+		end := i + factor*width
+		if end > len(w.Data) {
+			end = len(w.Data)
+		}
+		pixel := append([]int16{}, w.Data[i:i+width]...)
+		for j := i + width; j < end; j += width {
+			for c := 0; c < width; c += 2 {
+				if v := w.Data[j+c]; v < pixel[c] {
+					pixel[c] = v
+				}
+				if v := w.Data[j+c+1]; v > pixel[c+1] {
+					pixel[c+1] = v
+				}
+			}
+		}
+		zoomed.Data = append(zoomed.Data, pixel...)
+	}
+	return zoomed, nil
+}
+
+// header is the header of the binary format. Version 1 does not contain the
+// number of channels and is used for single channels.
+type header struct {
+	Version         int32
+	Flags           uint32
+	SampleRate      int32
+	SamplesPerPixel int32
+	Length          uint32
+}
+
+// version returns the format version required for the number of channels.
+func (w *Waveform) version() int {
+	if w.Channels == 1 {
+		return 1
+	}
+	return 2
+}
+
+// WriteTo writes the waveform in the binary format.
+func (w *Waveform) WriteTo(dst io.Writer) (int64, error) {
+	cw := &countWriter{w: dst}
+	h := header{
+		Version:         int32(w.version()),
+		SampleRate:      int32(w.SampleRate),
+		SamplesPerPixel: int32(w.SamplesPerPixel),
+		Length:          uint32(w.Len()),
+	}
+	if w.Bits == 8 {
+		h.Flags |= flag8Bit
+	}
+	if err := binary.Write(cw, binary.LittleEndian, h); err != nil {
+		return cw.n, errors.Wrap(err, "could not write header")
+	}
+	if h.Version > 1 {
+		if err := binary.Write(cw, binary.LittleEndian, int32(w.Channels)); err != nil {
+			return cw.n, errors.Wrap(err, "could not write number of channels")
+		}
+	}
+	var data interface{} = w.Data
+	if w.Bits == 8 {
+		data8 := make([]int8, len(w.Data))
+		for i, v := range w.Data {
+			data8[i] = int8(v)
+		}
+		data = data8
+	}
+	if err := binary.Write(cw, binary.LittleEndian, data); err != nil {
+		return cw.n, errors.Wrap(err, "could not write data")
+	}
+	return cw.n, nil
+}
+
+// Read reads a waveform in the binary format.
+func Read(r io.Reader) (*Waveform, error) {
+	var h header
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, errors.Wrap(err, "could not read header")
+	}
+	w := &Waveform{
+		SampleRate:      int(h.SampleRate),
+		SamplesPerPixel: int(h.SamplesPerPixel),
+		Channels:        1,
+		Bits:            16,
+	}
+	switch h.Version {
+	case 1:
+	case 2:
+		var chans int32
+		if err := binary.Read(r, binary.LittleEndian, &chans); err != nil {
+			return nil, errors.Wrap(err, "could not read number of channels")
+		}
+		w.Channels = int(chans)
+	default:
+		return nil, errors.Errorf("unsupported version %d", h.Version)
+	}
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	if h.Flags&flag8Bit != 0 {
+		w.Bits = 8
+	}
+	n := int64(h.Length) * 2 * int64(w.Channels)
+	for i := int64(0); i < n; i++ {
+		var v int16
+		var err error
+		if w.Bits == 8 {
+			var v8 int8
+			err = binary.Read(r, binary.LittleEndian, &v8)
+			v = int16(v8)
+		} else {
+			err = binary.Read(r, binary.LittleEndian, &v)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read data")
+		}
+		w.Data = append(w.Data, v)
+	}
+	return w, nil
+}
+
+// jsonWaveform is the JSON representation of a waveform.
+type jsonWaveform struct {
+	Version         int     `json:"version"`
+	Channels        int     `json:"channels"`
+	SampleRate      int     `json:"sample_rate"`
+	SamplesPerPixel int     `json:"samples_per_pixel"`
+	Bits            int     `json:"bits"`
+	Length          int     `json:"length"`
+	Data            []int16 `json:"data"`
+}
+
+// MarshalJSON encodes the waveform in the JSON format.
+func (w *Waveform) MarshalJSON() ([]byte, error) {
+	data := w.Data
+	if data == nil {
+		data = []int16{}
+	}
+	return json.Marshal(jsonWaveform{
+		Version:         w.version(),
+		Channels:        w.Channels,
+		SampleRate:      w.SampleRate,
+		SamplesPerPixel: w.SamplesPerPixel,
+		Bits:            w.Bits,
+		Length:          w.Len(),
+		Data:            data,
+	})
+}
+
+// UnmarshalJSON decodes a waveform in the JSON format.
+func (w *Waveform) UnmarshalJSON(p []byte) error {
+	var jw jsonWaveform
+	if err := json.Unmarshal(p, &jw); err != nil {
+		return err
+	}
+	if jw.Channels == 0 {
+		jw.Channels = 1
+	}
+	dec := Waveform{
+		SampleRate:      jw.SampleRate,
+		SamplesPerPixel: jw.SamplesPerPixel,
+		Channels:        jw.Channels,
+		Bits:            jw.Bits,
+		Data:            jw.Data,
+	}
+	if err := dec.validate(); err != nil {
+		return err
+	}
+	*w = dec
+	return nil
+}
+
+// Generator collects the minimum and maximum of interleaved samples.
+type Generator struct {
+	w      *Waveform
+	scale  float64
+	frames int       // Frames in the current pixel.
+	pixel  []float64 // Minimum and maximum per channel of the current pixel.
+}
+
+// NewGenerator creates a generator of a waveform with samplesPerPixel frames
+// per pixel and values of 8 or 16 bits.
+func NewGenerator(format wave.Format, samplesPerPixel, bits int) (*Generator, error) {
+	if samplesPerPixel <= 0 {
+		return nil, errors.Errorf("invalid number of samples per pixel %d", samplesPerPixel)
+	}
+	if bits != 8 && bits != 16 {
+		return nil, errors.Errorf("unsupported resolution of %d bits", bits)
+	}
+	if format.NumChans == 0 {
+		return nil, errors.New("no channels")
+	}
+	return &Generator{
+		w: &Waveform{
+			SampleRate:      int(format.SampleRate),
+			SamplesPerPixel: samplesPerPixel,
+			Channels:        int(format.NumChans),
+			Bits:            bits,
+		},
+		scale: float64(uint(1) << uint(bits-1)),
+		pixel: make([]float64, 2*int(format.NumChans)),
+	}, nil
+}
+
+// Process adds interleaved samples. Incomplete frames are ignored.
+func (g *Generator) Process(samples []float64) {
+	chans := g.w.Channels
+	for i := 0; i+chans <= len(samples); i += chans {
+		for c, v := range samples[i : i+chans] {
+			if g.frames == 0 || v < g.pixel[2*c] {
+				g.pixel[2*c] = v
+			}
+			if g.frames == 0 || v > g.pixel[2*c+1] {
+				g.pixel[2*c+1] = v
+			}
+		}
+		if g.frames++; g.frames == g.w.SamplesPerPixel {
+			g.flush()
+		}
+	}
+}
+
+// flush appends the current pixel to the waveform.
+func (g *Generator) flush() {
+	g.w.Data = g.appendPixel(g.w.Data)
+	g.frames = 0
+}
+
+// appendPixel appends the scaled values of the current pixel to data.
+func (g *Generator) appendPixel(data []int16) []int16 {
+	for _, v := range g.pixel {
+		v = math.Round(v * g.scale)
+		v = math.Max(math.Min(v, g.scale-1), -g.scale)
+		data = append(data, int16(v))
+	}
+	return data
+}
+
+// Waveform returns the waveform of all samples processed so far, including an
+// incomplete last pixel.
+func (g *Generator) Waveform() *Waveform {
+	w := *g.w
+	w.Data = append([]int16{}, g.w.Data...)
+	if g.frames > 0 {
+		w.Data = g.appendPixel(w.Data)
+	}
+	return &w
+}
+
+// Generate reads all samples from r until EOF or until ctx is done and returns
+// a waveform per zoom level, given in samples per pixel. Each level has to be
+// a multiple of the first one.
+func Generate(ctx context.Context, r *wave.Reader, bits int, levels ...int) ([]*Waveform, error) {
+	if len(levels) == 0 {
+		return nil, errors.New("no zoom levels")
+	}
+	g, err := NewGenerator(r.Format, levels[0], bits)
+	if err != nil {
+		return nil, err
+	}
+	err = wave.ReadBlocks(ctx, r, func(block []float64) error {
+		g.Process(block)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	w := g.Waveform()
+	waveforms := []*Waveform{w}
+	for _, level := range levels[1:] {
+		zoomed, err := w.Zoom(level)
+		if err != nil {
+			return nil, err
+		}
+		waveforms = append(waveforms, zoomed)
+	}
+	return waveforms, nil
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,149 @@
+package waveform_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bake/wave"
+	"github.com/bake/wave/waveform"
+	"github.com/orcaman/writerseeker"
+)
+
+func TestGenerate(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      2,
+		SampleRate:    8000,
+		ByteRate:      32000,
+		BlockAlign:    4,
+		BitsPerSample: 16,
+	}
+	samples := []int{
+		0, 0, 16384, -16384, -8192, 8192, 4096, 0,
+		-32768, 32767, 0, 0, 8192, 8192, -4096, -4096,
+		100, -100,
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	if err := wavw.Samples(samples); err != nil {
+		t.Fatalf("could not write samples: %v", err)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+	wavr, err := wave.NewReader(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	waveforms, err := waveform.Generate(context.Background(), wavr, 8, 2, 4, 8)
+	if err != nil {
+		t.Fatalf("could not generate waveforms: %v", err)
+	}
+	tt := []string{
+		"[0 64 -64 0 -32 16 0 32 -128 0 0 127 -16 32 -16 32 0 0 0 0]",
+		"[-32 64 -64 32 -128 32 -16 127 0 0 0 0]",
+		"[-128 64 -64 127 0 0 0 0]",
+	}
+	for i, data := range tt {
+		if got := fmt.Sprint(waveforms[i].Data); got != data {
+			t.Fatalf("expected data of level %d to be\n%s, got\n%s", i, data, got)
+		}
+	}
+}
+
+func TestWaveformEncoding(t *testing.T) {
+	tt := []struct {
+		w    *waveform.Waveform
+		bin  []byte
+		json string
+	}{
+		{
+			&waveform.Waveform{SampleRate: 44100, SamplesPerPixel: 256, Channels: 1, Bits: 8, Data: []int16{-3, 5}},
+			[]byte{
+				//                 1,                  flags,                  44100,
+				0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x44, 0xac, 0x00, 0x00,
+				//               256,                      1,   -3,    5,
+				0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0xfd, 0x05,
+			},
+			`{"version":1,"channels":1,"sample_rate":44100,"samples_per_pixel":256,"bits":8,"length":1,"data":[-3,5]}`,
+		},
+		{
+			&waveform.Waveform{SampleRate: 8000, SamplesPerPixel: 2, Channels: 2, Bits: 16, Data: []int16{-300, 500, -1, 1}},
+			[]byte{
+				//                 2,                  flags,                   8000,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x1f, 0x00, 0x00,
+				//                 2,                      1,                      2,
+				0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+				//    -300,        500,         -1,          1,
+				0xd4, 0xfe, 0xf4, 0x01, 0xff, 0xff, 0x01, 0x00,
+			},
+			`{"version":2,"channels":2,"sample_rate":8000,"samples_per_pixel":2,"bits":16,"length":1,"data":[-300,500,-1,1]}`,
+		},
+	}
+	for _, tc := range tt {
+		var buf bytes.Buffer
+		if _, err := tc.w.WriteTo(&buf); err != nil {
+			t.Fatalf("could not write waveform: %v", err)
+		}
+		if fmt.Sprintf("% x", buf.Bytes()) != fmt.Sprintf("% x", tc.bin) {
+			t.Fatalf("expected binary waveform to be\n% x, got\n% x", tc.bin, buf.Bytes())
+		}
+		w, err := waveform.Read(&buf)
+		if err != nil {
+			t.Fatalf("could not read waveform: %v", err)
+		}
+		if fmt.Sprint(w) != fmt.Sprint(tc.w) {
+			t.Fatalf("expected waveform to be %v, got %v", tc.w, w)
+		}
+		p, err := json.Marshal(tc.w)
+		if err != nil {
+			t.Fatalf("could not encode waveform: %v", err)
+		}
+		if string(p) != tc.json {
+			t.Fatalf("expected json to be\n%s, got\n%s", tc.json, p)
+		}
+		w = &waveform.Waveform{}
+		if err := json.Unmarshal(p, w); err != nil {
+			t.Fatalf("could not decode waveform: %v", err)
+		}
+		if fmt.Sprint(w) != fmt.Sprint(tc.w) {
+			t.Fatalf("expected waveform to be %v, got %v", tc.w, w)
+		}
+	}
+}
+
+func TestInvalidWaveform(t *testing.T) {
+	bin := []byte{
+		//                 1,                  flags,                  44100,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x44, 0xac, 0x00, 0x00,
+		//                 0,                      0,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	if _, err := waveform.Read(bytes.NewReader(bin)); err == nil {
+		t.Fatalf("expected waveform without samples per pixel not to be read")
+	}
+	for _, p := range []string{
+		`{"channels":1,"samples_per_pixel":0,"data":[]}`,
+		`{"channels":-1,"samples_per_pixel":256,"data":[]}`,
+		`{"channels":2,"samples_per_pixel":256,"data":[1,2,3]}`,
+	} {
+		if err := json.Unmarshal([]byte(p), &waveform.Waveform{}); err == nil {
+			t.Fatalf("expected %s not to be decoded", p)
+		}
+	}
+	for _, w := range []*waveform.Waveform{
+		{SamplesPerPixel: 0, Channels: 1},
+		{SamplesPerPixel: 256, Channels: 0},
+		{SamplesPerPixel: 256, Channels: 2, Data: []int16{1, 2, 3}},
+	} {
+		if _, err := w.Zoom(512); err == nil {
+			t.Fatalf("expected %v not to be zoomed", w)
+		}
+	}
+}
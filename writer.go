@@ -2,24 +2,65 @@ package wave
 
 import (
 	"io"
+	"math/rand"
 
 	"github.com/bake/wave/riff"
 	"github.com/pkg/errors"
 )
 
+// maxSilence is the maximum number of frames in a single silence chunk.
+const maxSilence = 1<<32 - 1
+
 // Writer writes samples to an io.WriteSeeker.
 type Writer struct {
-	rw  *riff.Writer
-	cw  *riff.Writer
-	fmt Format
+	ws      io.WriteSeeker
+	rw      *riff.Writer
+	lw      *riff.Writer // Wave list, if silence is compacted.
+	cw      *riff.Writer // Current data chunk.
+	fmt     Format
+	compact int64   // Minimum number of silent frames to compact.
+	silence silence // Value of silent samples.
+	silent  int64   // Number of pending silent frames.
+	frame   []byte  // Incomplete frame.
+	bext    *Bext
+	bextPos int64 // Position of the bext chunk header in ws.
+	dither  *rand.Rand
+	cues    []Cue
+	signed  bool
+	clip    ClipPolicy
+	clipped int64 // Number of samples that were out of range.
+}
+
+// WriterOption configures optional behaviour of a Writer.
+type WriterOption func(*Writer)
+
+// CompactSilence stores runs of at least frames silent frames in silence
+// chunks instead of writing them as samples. Data and silence chunks are then
+// written into a wave list, which is not supported by all programs.
+func CompactSilence(frames int64) WriterOption {
+	return func(wavw *Writer) { wavw.compact = frames }
+}
+
+// Dither adds triangular noise of one least significant bit to samples written
+// by Floats. This masks quantization distortion when reducing the bit depth.
+func Dither() WriterOption {
+	return func(wavw *Writer) { wavw.dither = rand.New(rand.NewSource(1)) }
 }
 
 // NewWriter creates a new WAVE Writer.
-func NewWriter(ws io.WriteSeeker, format Format) (*Writer, error) {
+func NewWriter(ws io.WriteSeeker, format Format, opts ...WriterOption) (*Writer, error) {
+	wavw := &Writer{ws: ws, fmt: format, silence: newSilence(format)}
+	for _, opt := range opts {
+		opt(wavw)
+	}
+	if wavw.compact > 0 && format.BlockAlign == 0 {
+		return nil, errors.New("can not compact silence without block align")
+	}
 	rw, err := riff.NewWriter(ws, "WAVE")
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create new riff reader")
 	}
+	wavw.rw = rw
 	cw, err := rw.Chunk("fmt ")
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create format chunk")
@@ -30,27 +71,35 @@ func NewWriter(ws io.WriteSeeker, format Format) (*Writer, error) {
 	if err := cw.Close(); err != nil {
 		return nil, errors.Wrap(err, "could not close format chunk")
 	}
-	cw, err = rw.Chunk("data")
-	if err != nil {
+	if wavw.bext != nil {
+		if err := wavw.writeBext(); err != nil {
+			return nil, err
+		}
+	}
+	if wavw.compact > 0 {
+		if wavw.lw, err = rw.Chunk("LIST"); err != nil {
+			return nil, errors.Wrap(err, "could not create wave list")
+		}
+		if _, err := wavw.lw.Write([]byte("wavl")); err != nil {
+			return nil, errors.Wrap(err, "could not write list type")
+		}
+		return wavw, nil
+	}
+	if wavw.cw, err = rw.Chunk("data"); err != nil {
 		return nil, errors.Wrap(err, "could not create data chunk")
 	}
-	return &Writer{rw, cw, format}, nil
+	return wavw, nil
 }
 
-// Sample writes a sample.
+// Sample writes a sample. 8 bit samples are unsigned unless the writer has
+// been created with WriteSigned. Samples that are out of range are handled as
+// set by Clip.
 func (wavw *Writer) Sample(s int) error {
-	var p []byte
-	switch wavw.fmt.BitsPerSample {
-	case 8:
-		p = []byte{byte(s)}
-	case 16:
-		p = []byte{byte(s), byte(s >> 8)}
-	case 24:
-		p = []byte{byte(s), byte(s >> 8), byte(s >> 16)}
-	case 32:
-		p = []byte{byte(s), byte(s >> 8), byte(s >> 16), byte(s >> 24)}
-	}
-	if _, err := wavw.cw.Write(p); err != nil {
+	s, err := wavw.limit(s)
+	if err != nil {
+		return err
+	}
+	if _, err := wavw.Write(wavw.fmt.appendSample(nil, s+wavw.offset())); err != nil {
 		return errors.Wrap(err, "could not write sample")
 	}
 	return nil
@@ -66,11 +115,151 @@ func (wavw *Writer) Samples(samples []int) error {
 	return nil
 }
 
+// Write writes raw bytes to the data chunk. They have to be encoded according
+// to the writers format.
+func (wavw *Writer) Write(p []byte) (int, error) {
+	if wavw.lw == nil {
+		return wavw.cw.Write(p)
+	}
+	for i := 0; i < len(p); {
+		n := int(wavw.fmt.BlockAlign) - len(wavw.frame)
+		if n > len(p)-i {
+			n = len(p) - i
+		}
+		wavw.frame = append(wavw.frame, p[i:i+n]...)
+		i += n
+		if len(wavw.frame) < int(wavw.fmt.BlockAlign) {
+			continue
+		}
+		if err := wavw.writeFrame(wavw.frame); err != nil {
+			return i, err
+		}
+		wavw.frame = wavw.frame[:0]
+	}
+	return len(p), nil
+}
+
+// ReadFrom writes raw bytes from r to the data chunk until EOF. They have to be
+// encoded according to the writers format.
+func (wavw *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if wavw.lw == nil {
+		return io.Copy(wavw.cw, r)
+	}
+	// Hide ReadFrom from io.Copy.
+	return io.Copy(struct{ io.Writer }{wavw}, r)
+}
+
+// writeFrame counts silent frames and writes all others into a data chunk.
+func (wavw *Writer) writeFrame(frame []byte) error {
+	if wavw.silence.isSilent(frame) {
+		wavw.silent++
+		return nil
+	}
+	if err := wavw.flushSilence(); err != nil {
+		return err
+	}
+	_, err := wavw.writeData(frame)
+	return err
+}
+
+// flushSilence writes pending silent frames. Runs that are too short to be
+// compacted are written as samples.
+func (wavw *Writer) flushSilence() error {
+	frames := wavw.silent
+	wavw.silent = 0
+	if frames == 0 {
+		return nil
+	}
+	if frames < wavw.compact {
+		data := io.LimitReader(wavw.silence, frames*int64(wavw.fmt.BlockAlign))
+		if _, err := io.Copy(writerFunc(wavw.writeData), data); err != nil {
+			return errors.Wrap(err, "could not write silence")
+		}
+		return nil
+	}
+	if err := wavw.closeData(); err != nil {
+		return err
+	}
+	for ; frames > 0; frames -= maxSilence {
+		n := frames
+		if n > maxSilence {
+			n = maxSilence
+		}
+		sw, err := wavw.lw.Chunk("slnt")
+		if err != nil {
+			return errors.Wrap(err, "could not create silence chunk")
+		}
+		if err := encodeSilence(sw, n); err != nil {
+			return errors.Wrap(err, "could not encode silence chunk")
+		}
+		if err := sw.Close(); err != nil {
+			return errors.Wrap(err, "could not close silence chunk")
+		}
+	}
+	return nil
+}
+
+// writeData writes to the current data chunk inside the wave list and creates
+// one if necessary.
+func (wavw *Writer) writeData(p []byte) (int, error) {
+	if wavw.cw == nil {
+		cw, err := wavw.lw.Chunk("data")
+		if err != nil {
+			return 0, errors.Wrap(err, "could not create data chunk")
+		}
+		wavw.cw = cw
+	}
+	return wavw.cw.Write(p)
+}
+
+// closeData closes the current data chunk inside the wave list, if any.
+func (wavw *Writer) closeData() error {
+	if wavw.cw == nil {
+		return nil
+	}
+	cw := wavw.cw
+	wavw.cw = nil
+	return errors.Wrap(cw.Close(), "could not close data chunk")
+}
+
 // Close the underlying RIFF writers. The file writer needs to be closed
 // separately.
 func (wavw *Writer) Close() error {
-	if err := wavw.cw.Close(); err != nil {
+	if err := wavw.closeSamples(); err != nil {
 		return err
 	}
-	return wavw.rw.Close()
+	if err := wavw.writeCues(); err != nil {
+		return err
+	}
+	if err := wavw.rw.Close(); err != nil {
+		return err
+	}
+	if wavw.bext == nil {
+		return nil
+	}
+	return wavw.rewriteBext()
 }
+
+// closeSamples closes the data chunks and the wave list.
+func (wavw *Writer) closeSamples() error {
+	if wavw.lw == nil {
+		return wavw.cw.Close()
+	}
+	if err := wavw.flushSilence(); err != nil {
+		return err
+	}
+	if len(wavw.frame) > 0 {
+		if _, err := wavw.writeData(wavw.frame); err != nil {
+			return err
+		}
+	}
+	if err := wavw.closeData(); err != nil {
+		return err
+	}
+	return errors.Wrap(wavw.lw.Close(), "could not close wave list")
+}
+
+// writerFunc turns a function into an io.Writer.
+type writerFunc func(p []byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) { return fn(p) }
@@ -1,7 +1,9 @@
 package wave_test
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"testing"
@@ -61,6 +63,53 @@ func TestWriter(t *testing.T) {
 	}
 }
 
+func TestWriterReadFrom(t *testing.T) {
+	format := wave.Format{
+		AudioFormat:   1,
+		NumChans:      2,
+		SampleRate:    22050,
+		ByteRate:      88200,
+		BlockAlign:    4,
+		BitsPerSample: 16,
+	}
+	wavr, err := wave.NewReader(bytes.NewReader(exampleInt16Wave()))
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	ws := &writerseeker.WriterSeeker{}
+	wavw, err := wave.NewWriter(ws, format)
+	if err != nil {
+		t.Fatalf("could not create wave writer: %v", err)
+	}
+	n, err := io.Copy(wavw, wavr)
+	if err != nil {
+		t.Fatalf("could not copy data: %v", err)
+	}
+	if n != 44 {
+		t.Fatalf("expected 44 bytes to be copied, got %d", n)
+	}
+	if err := wavw.Close(); err != nil {
+		t.Fatalf("could not close wave writer: %v", err)
+	}
+
+	wavr, err = wave.NewReader(ws.Reader())
+	if err != nil {
+		t.Fatalf("could not create wave reader: %v", err)
+	}
+	samples, err := wavr.Samples()
+	if err != nil {
+		t.Fatalf("could not read samples: %v", err)
+	}
+	out := []int{
+		0, 0, 5924, -3298, 4924, 5180, -1770, -1768,
+		-6348, -23005, -3524, -3548, -12783, 3354,
+		0, 0, 5924, -3298, 4924, 5180, -1770, -1768,
+	}
+	if fmt.Sprint(samples) != fmt.Sprint(out) {
+		t.Fatalf("expected samples to be\n%v, got\n%v", out, samples)
+	}
+}
+
 func ExampleWriter() {
 	format := wave.Format{
 		AudioFormat:   1,